@@ -0,0 +1,55 @@
+package main
+
+import (
+	le "GLox/internal/loxerror"
+	"GLox/internal/parser"
+	"GLox/internal/scanner"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// astCommand glox ast [-format json|dot|sexpr] <file>，输出解析得到的语法树，不会执行脚本
+func astCommand(args []string) int {
+	fs := flag.NewFlagSet("ast", flag.ExitOnError)
+	format := fs.String("format", "sexpr", "output format: json, dot or sexpr")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: glox ast [-format json|dot|sexpr] <file>")
+		return 2
+	}
+
+	bs, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	le.HadError = false
+	program, errs := parser.NewParser(scanner.NewScanner(string(bs)).ScanTokens()).Parse()
+	if len(errs) > 0 {
+		fmt.Fprintln(os.Stderr, errs)
+	}
+	if le.HadError || len(errs) > 0 {
+		return 1
+	}
+
+	switch *format {
+	case "json":
+		err = parser.WriteJSON(os.Stdout, program)
+	case "dot":
+		err = parser.WriteDot(os.Stdout, program)
+	case "sexpr":
+		_, err = fmt.Println(new(parser.Printer).PrintProgram(program))
+	default:
+		err = fmt.Errorf("unknown format %q, expect json, dot or sexpr", *format)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	return 0
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"GLox/internal/interpreter"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var errCoverage = errors.New("coverage is below the threshold")
+
+// coverageFlags run和test子命令共用的覆盖率参数
+func coverageFlags(fs *flag.FlagSet) {
+	fs.StringVar(&options.coverage, "coverage", "", "write an LCOV coverage report to the file")
+	fs.StringVar(&options.coverageHTML, "coverage-html", "", "write an HTML coverage report for every file into the directory")
+	fs.Float64Var(&options.coverageMin, "coverage-min", 0, "fail if the line coverage in percent is lower")
+	fs.Float64Var(&options.coverageBranchMin, "coverage-branch-min", 0, "fail if the branch coverage in percent is lower")
+}
+
+func coverageEnabled() bool {
+	return options.coverage != "" || options.coverageHTML != "" || options.coverageMin > 0 || options.coverageBranchMin > 0
+}
+
+// reportCoverage 输出覆盖率的报告，覆盖率低于阈值时返回errCoverage
+func reportCoverage(coverages []*interpreter.Coverage) error {
+	if options.coverage != "" {
+		file, err := os.Create(options.coverage)
+		if err != nil {
+			return err
+		}
+		err = interpreter.WriteLCOV(file, coverages)
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if options.coverageHTML != "" {
+		if err := os.MkdirAll(options.coverageHTML, 0755); err != nil {
+			return err
+		}
+
+		for _, c := range coverages {
+			err := writeHTMLCoverage(c)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	var linesHit, linesTotal, branchesHit, branchesTotal int
+	for _, c := range coverages {
+		hit, total := c.LineRate()
+		linesHit, linesTotal = linesHit+hit, linesTotal+total
+		hit, total = c.BranchRate()
+		branchesHit, branchesTotal = branchesHit+hit, branchesTotal+total
+	}
+	lines, branches := percent(linesHit, linesTotal), percent(branchesHit, branchesTotal)
+	fmt.Fprintf(os.Stderr, "coverage: %.1f%% of lines, %.1f%% of branches\n", lines, branches)
+
+	if lines < options.coverageMin || branches < options.coverageBranchMin {
+		return errCoverage
+	}
+
+	return nil
+}
+
+// writeHTMLCoverage 报告的文件名由源文件的路径得到，比如 lox/a_test.lox -> lox_a_test.lox.html
+func writeHTMLCoverage(c *interpreter.Coverage) error {
+	name := strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(filepath.Clean(c.File))
+	file, err := os.Create(filepath.Join(options.coverageHTML, name+".html"))
+	if err != nil {
+		return err
+	}
+
+	err = c.WriteHTML(file)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+func percent(hit, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+
+	return float64(hit) * 100 / float64(total)
+}
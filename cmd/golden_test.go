@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// goldenDir 中的每一个.lox文件都是一个测试用例，期望的结果写在源码的注释中:
+//
+//	print 1 + 2; // expect: 3
+//	print nil.a; // expect runtime error: Only instances have attributes.
+//	print this;  // expect resolve error: Can't use 'this' outside of a class.
+//	var a = 1 +; // expect parse error: Expect expression, found ';'.
+const goldenDir = "../resources/lox"
+
+var (
+	expectOutput       = regexp.MustCompile(`// expect: ?(.*)$`)
+	expectRuntimeError = regexp.MustCompile(`// expect runtime error: (.+)$`)
+	expectResolveError = regexp.MustCompile(`// expect resolve error: (.+)$`)
+	expectParseError   = regexp.MustCompile(`// expect parse error: (.+)$`)
+	// 以前的.lox文件在末尾用 // result: 列出输出，runner不认识这种写法，所以它们都改成了 // expect:
+	legacyResult = regexp.MustCompile(`(?m)^// result:`)
+)
+
+type expectation struct {
+	output        []string
+	runtimeError  string
+	resolveErrors []diagnostic
+	parseErrors   []diagnostic
+}
+
+type diagnostic struct {
+	line    int
+	message string
+}
+
+func parseExpectation(source string) *expectation {
+	e := new(expectation)
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if m := expectOutput.FindStringSubmatch(text); m != nil {
+			e.output = append(e.output, m[1])
+		} else if m := expectRuntimeError.FindStringSubmatch(text); m != nil {
+			e.runtimeError = fmt.Sprintf("Runtime error at line %d : %s", line, m[1])
+		} else if m := expectResolveError.FindStringSubmatch(text); m != nil {
+			e.resolveErrors = append(e.resolveErrors, diagnostic{line: line, message: m[1]})
+		} else if m := expectParseError.FindStringSubmatch(text); m != nil {
+			e.parseErrors = append(e.parseErrors, diagnostic{line: line, message: m[1]})
+		}
+	}
+
+	return e
+}
+
+func TestGolden(t *testing.T) {
+	testGolden(t)
+}
+
+// TestGoldenOptimized 开启 -O 之后所有的golden测试仍然要得到相同的结果
+func TestGoldenOptimized(t *testing.T) {
+	options.optimize = true
+	defer func() { options.optimize = false }()
+
+	testGolden(t)
+}
+
+func testGolden(t *testing.T) {
+	var files []string
+	err := filepath.Walk(goldenDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && filepath.Ext(path) == ".lox" {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		name, _ := filepath.Rel(goldenDir, file)
+		t.Run(name, func(t *testing.T) {
+			source, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if legacyResult.Match(source) {
+				t.Fatal("use // expect: comments instead of a // result: block")
+			}
+			runGolden(t, string(source))
+		})
+	}
+}
+
+func runGolden(t *testing.T, source string) {
+	expected := parseExpectation(source)
+
+	// scanner和resolver通过log报告错误，parser的错误输出到stderr
+	var stdout, logs bytes.Buffer
+	log.SetOutput(&logs)
+	stderr = &logs
+	defer func() {
+		log.SetOutput(os.Stderr)
+		stderr = os.Stderr
+	}()
+
+	err := interpret(source, &stdout)
+
+	switch {
+	case len(expected.parseErrors) > 0:
+		if err != errParse {
+			t.Fatalf("expected parse errors, but got %v", err)
+		}
+		// 词法错误通过log报告，格式和resolve error相同
+		for _, d := range expected.parseErrors {
+			if !containsLine(logs.String(), fmt.Sprintf("[parse error] line %d:", d.line), ": "+d.message) &&
+				!containsLine(logs.String(), fmt.Sprintf("[line %d ] Error", d.line), ": "+d.message) {
+				t.Errorf("missing parse error at line %d %q in:\n%s", d.line, d.message, logs.String())
+			}
+		}
+	case len(expected.resolveErrors) > 0:
+		if err != errResolve {
+			t.Fatalf("expected resolve errors, but got %v", err)
+		}
+		for _, d := range expected.resolveErrors {
+			if !containsLine(logs.String(), fmt.Sprintf("[line %d ] Error", d.line), ": "+d.message) {
+				t.Errorf("missing resolve error at line %d %q in:\n%s", d.line, d.message, logs.String())
+			}
+		}
+	case expected.runtimeError != "":
+		if err == nil || err.Error() != expected.runtimeError {
+			t.Errorf("expected runtime error %q, but got %v", expected.runtimeError, err)
+		}
+	default:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	output := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	if stdout.Len() == 0 {
+		output = nil
+	}
+
+	if len(output) != len(expected.output) {
+		t.Fatalf("expected %d lines of output %q, but got %d lines %q", len(expected.output), expected.output, len(output), output)
+	}
+	for n := range output {
+		if output[n] != expected.output[n] {
+			t.Errorf("line %d of output: expected %q, but got %q", n+1, expected.output[n], output[n])
+		}
+	}
+}
+
+// containsLine 判断logs中是否有一行同时包含prefix和suffix
+func containsLine(logs, prefix, suffix string) bool {
+	for _, line := range strings.Split(logs, "\n") {
+		if strings.Contains(line, prefix) && strings.HasSuffix(line, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,15 +2,29 @@ package main
 
 import (
 	"flag"
+	"os"
 )
 
 var source string
 
-func init() {
-	flag.StringVar(&source, "s", "", "Lox source code file path")
-	flag.Parse()
+// commands glox支持的子命令，不带子命令的时候兼容原来的 -s 参数
+var commands = map[string]func(args []string) int{
+	"run":    runCommand,
+	"test":   testCommand,
+	"ast":    astCommand,
+	"tokens": tokensCommand,
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[2:]))
+		}
+	}
+
+	// 参数在main中而不是init中解析，否则go test启动时flag.Parse会遇到测试自己的参数
+	flag.StringVar(&source, "s", "", "Lox source code file path")
+	flag.Parse()
+
 	runApp(source)
 }
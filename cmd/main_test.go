@@ -0,0 +1,13 @@
+package main
+
+import (
+	"flag"
+	"testing"
+)
+
+// 测试启动时main没有执行，包初始化不能注册或者解析glox的参数
+func TestFlagsParsedInMain(t *testing.T) {
+	if flag.Lookup("s") != nil {
+		t.Error("the -s flag must be registered in main, not in init")
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"GLox/internal/interpreter"
+	"fmt"
+	"os"
+)
+
+// startProfile 在设置了 -profile 参数时为interpreter开启profiler，返回的函数在执行结束后写出结果
+func startProfile(i *interpreter.Interpreter) func() {
+	if options.profile == "" {
+		return func() {}
+	}
+
+	profiler := interpreter.NewProfiler()
+	i.SetProfiler(profiler)
+
+	return func() {
+		i.SetProfiler(nil)
+
+		file, err := os.Create(options.profile)
+		if err == nil {
+			err = profiler.WriteFolded(file)
+			if cerr := file.Close(); err == nil {
+				err = cerr
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+
+		profiler.WriteSummary(os.Stderr, options.profileTop)
+	}
+}
@@ -3,15 +3,60 @@ package main
 import (
 	"GLox/internal/interpreter"
 	le "GLox/internal/loxerror"
+	"GLox/internal/numeric"
 	"GLox/internal/parser"
 	"GLox/internal/resolver"
 	"GLox/internal/scanner"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
 )
 
+var (
+	errParse   = errors.New("parse error")
+	errResolve = errors.New("resolve error")
+)
+
+// stderr 是parser报告语法错误的地方，测试时替换成buffer
+var stderr io.Writer = os.Stderr
+
+// options 是run子命令的参数，prepare会把它们应用到新建的interpreter上
+var options struct {
+	limits     interpreter.Limits
+	optimize   bool
+	numbers    numeric.Mode
+	profile    string
+	profileTop int
+
+	coverage          string
+	coverageHTML      string
+	coverageMin       float64
+	coverageBranchMin float64
+}
+
+// runCommand glox run [-O] [-numbers float|exact] [-max-steps n] [-timeout d] [-max-depth n] [-max-memory n] [-profile out.folded] [-coverage lcov.info] <file>
+func runCommand(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	optimizeFlag(fs)
+	numbersFlag(fs)
+	fs.IntVar(&options.limits.MaxSteps, "max-steps", 0, "maximum number of evaluated statements and expressions")
+	fs.DurationVar(&options.limits.Timeout, "timeout", 0, "maximum wall-clock execution time, e.g. 2s")
+	fs.IntVar(&options.limits.MaxCallDepth, "max-depth", 0, "maximum depth of nested function calls")
+	fs.IntVar(&options.limits.MaxMemory, "max-memory", 0, "approximate maximum bytes allocated for lists, instances and strings")
+	fs.StringVar(&options.profile, "profile", "", "write a folded-stack profile to the file and print the hot spots")
+	fs.IntVar(&options.profileTop, "profile-top", 10, "number of functions and call sites in the profile summary")
+	coverageFlags(fs)
+	_ = fs.Parse(args)
+
+	runApp(fs.Arg(0))
+
+	return 0
+}
+
 func runApp(source string) {
 	if source != "" {
 		runFile(source)
@@ -27,30 +72,93 @@ func runFile(path string) {
 	}
 	sc := string(bytes)
 
-	run(sc)
+	run(path, sc)
+}
+
+func run(path, sc string) {
+	i, stmts, err := prepare(sc, os.Stdout)
+	if err == nil {
+		var coverage *interpreter.Coverage
+		if coverageEnabled() {
+			coverage = interpreter.NewCoverage(path, sc)
+			coverage.Register(stmts)
+			i.SetCoverage(coverage)
+		}
+
+		stop := startProfile(i)
+		err = i.Interpret(stmts)
+		stop()
+
+		if coverage != nil {
+			if cerr := reportCoverage([]*interpreter.Coverage{coverage}); err == nil {
+				err = cerr
+			}
+		}
+	}
+
+	switch err {
+	case nil:
+	case errCoverage:
+		os.Exit(1)
+	case errParse:
+		os.Exit(-1)
+	case errResolve:
+		os.Exit(-2)
+	default:
+		fatal(err.Error(), 0)
+	}
+}
+
+// interpret 依次执行 scanner -> parser -> resolver -> interpreter，print语句输出到stdout
+func interpret(sc string, stdout io.Writer) error {
+	i, stmts, err := prepare(sc, stdout)
+	if err != nil {
+		return err
+	}
+
+	return i.Interpret(stmts)
 }
 
-func run(sc string) {
+// prepare 完成执行之前的 scanner -> parser -> resolver 阶段，返回可以直接执行的interpreter和语法树
+func prepare(sc string, stdout io.Writer) (*interpreter.Interpreter, []parser.Stmt, error) {
+	le.HadError, le.HadResolveError = false, false
+
 	s := scanner.NewScanner(sc)
+	s.SetNumberMode(options.numbers)
 	tokens := s.ScanTokens()
 
 	p := parser.NewParser(tokens)
-	stmts := p.Parse()
-	if le.HadError {
-		os.Exit(-1)
+	stmts, errs := p.Parse()
+	if len(errs) > 0 {
+		fmt.Fprintln(stderr, errs)
+	}
+	if le.HadError || len(errs) > 0 {
+		return nil, nil, errParse
 	}
 
 	i := interpreter.NewInterpreter()
+	i.SetOutput(stdout)
+	i.SetLimits(options.limits)
 	r := resolver.NewResolver(i)
 	r.ResolveStmt(stmts...)
 	if le.HadResolveError {
-		os.Exit(-2)
+		return nil, nil, errResolve
 	}
 
-	err := i.Interpret(stmts)
-	if err != nil {
-		fatal(err.Error(), 0)
+	if options.optimize {
+		stmts = i.Optimize(stmts)
 	}
+
+	return i, stmts, nil
+}
+
+func optimizeFlag(fs *flag.FlagSet) {
+	fs.BoolVar(&options.optimize, "O", false, "fold constants, remove dead code and inline trivial getters before running")
+}
+
+// numbersFlag 源码中的 //glox:numbers=... pragma 会覆盖这个参数
+func numbersFlag(fs *flag.FlagSet) {
+	fs.Var(&options.numbers, "numbers", "type of number literals without suffix: float or exact (big integers and decimals)")
 }
 
 func fatal(msg string, signal int) {
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"GLox/internal/interpreter"
+	le "GLox/internal/loxerror"
+	"GLox/internal/parser"
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// testResult 是一个Lox测试的执行结果，err为nil表示测试通过
+type testResult struct {
+	file     string
+	name     string
+	duration time.Duration
+	output   string
+	err      error
+}
+
+func (r *testResult) failed() bool {
+	_, ok := r.err.(*le.AssertionError)
+	return ok
+}
+
+// testCommand glox test [-O] [-numbers float|exact] [-junit report.xml] <file or directory>...
+// 目录中所有以 _test.lox 结尾的文件都会被执行，文件中通过 test(name, fn) 注册的测试会被依次运行
+func testCommand(args []string) int {
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	junit := fs.String("junit", "", "write a JUnit XML report to the file")
+	optimizeFlag(fs)
+	numbersFlag(fs)
+	coverageFlags(fs)
+	_ = fs.Parse(args)
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, err := discoverTests(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	var results []*testResult
+	var coverages []*interpreter.Coverage
+	for _, file := range files {
+		fileResults, coverage := runTestFile(file)
+		results = append(results, fileResults...)
+		if coverage != nil {
+			coverages = append(coverages, coverage)
+		}
+	}
+
+	writeTextReport(os.Stdout, results)
+
+	if coverageEnabled() {
+		switch err := reportCoverage(coverages); err {
+		case nil:
+		case errCoverage:
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		default:
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	if *junit != "" {
+		var buffer bytes.Buffer
+		if err := writeJUnitReport(&buffer, results); err == nil {
+			err = ioutil.WriteFile(*junit, buffer.Bytes(), 0644)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	for _, result := range results {
+		if result.err != nil {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func discoverTests(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		// 直接指定的文件不要求以 _test.lox 结尾
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && strings.HasSuffix(path, "_test.lox") {
+				files = append(files, path)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// runTestFile 先执行一遍文件找出其中注册的测试，然后每个测试都在一个全新的interpreter中重新执行文件之后再运行，
+// 这样测试之间不会通过全局变量互相影响。开启了覆盖率的时候，所有的执行都记录到同一个Coverage中
+func runTestFile(file string) ([]*testResult, *interpreter.Coverage) {
+	bs, err := ioutil.ReadFile(file)
+	if err != nil {
+		return []*testResult{{file: file, err: err}}, nil
+	}
+	source := string(bs)
+
+	var coverage *interpreter.Coverage
+	if coverageEnabled() {
+		coverage = interpreter.NewCoverage(file, source)
+	}
+	prepareTest := func(stdout io.Writer) (*interpreter.Interpreter, []parser.Stmt, error) {
+		i, stmts, err := prepare(source, stdout)
+		if err == nil && coverage != nil {
+			coverage.Register(stmts)
+			i.SetCoverage(coverage)
+		}
+
+		return i, stmts, err
+	}
+
+	i, stmts, err := prepareTest(ioutil.Discard)
+	if err == nil {
+		err = i.Interpret(stmts)
+	}
+	if err != nil {
+		return []*testResult{{file: file, err: err}}, coverage
+	}
+
+	var results []*testResult
+	for n, tc := range i.Tests() {
+		result := &testResult{file: file, name: tc.Name}
+
+		var output bytes.Buffer
+		isolated, stmts, err := prepareTest(&output)
+		if err == nil {
+			err = isolated.Interpret(stmts)
+		}
+		var registered interpreter.TestCase
+		if err == nil {
+			registered, err = registeredTest(isolated.Tests(), n, tc.Name)
+		}
+		if err != nil {
+			result.err = err
+		} else {
+			start := time.Now()
+			_, result.err = registered.Fn.Call(isolated, nil, nil)
+			result.duration = time.Since(start)
+		}
+
+		result.output = output.String()
+		results = append(results, result)
+	}
+
+	return results, coverage
+}
+
+// registeredTest 返回重新执行文件之后注册的第n个测试。脚本依赖时间之类的时候注册的测试可能和第一次不同，
+// 这时返回错误而不是运行另一个测试
+func registeredTest(tests []interpreter.TestCase, n int, name string) (interpreter.TestCase, error) {
+	if n >= len(tests) || tests[n].Name != name {
+		return interpreter.TestCase{}, fmt.Errorf("test %q was not registered again in a fresh interpreter", name)
+	}
+
+	return tests[n], nil
+}
+
+func writeTextReport(w io.Writer, results []*testResult) {
+	var failures int
+	var total time.Duration
+	for _, result := range results {
+		total += result.duration
+		if result.err == nil {
+			fmt.Fprintf(w, "PASS  %s: %s (%v)\n", result.file, result.name, result.duration)
+			continue
+		}
+
+		failures++
+		fmt.Fprintf(w, "FAIL  %s: %s (%v)\n", result.file, result.name, result.duration)
+		fmt.Fprintf(w, "      %v\n", result.err)
+		for _, line := range strings.Split(strings.TrimSuffix(result.output, "\n"), "\n") {
+			if line != "" {
+				fmt.Fprintf(w, "      | %s\n", line)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "%d tests, %d passed, %d failed (%v)\n", len(results), len(results)-failures, failures, total)
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnitReport 每个文件对应一个testsuite，断言失败记为failure，其余错误记为error
+func writeJUnitReport(w io.Writer, results []*testResult) error {
+	var suites junitTestSuites
+	index := make(map[string]int)
+	for _, result := range results {
+		n, ok := index[result.file]
+		if !ok {
+			n = len(suites.Suites)
+			index[result.file] = n
+			suites.Suites = append(suites.Suites, junitTestSuite{Name: result.file})
+		}
+
+		suite := &suites.Suites[n]
+		tc := junitTestCase{
+			Name:      result.name,
+			ClassName: result.file,
+			Time:      seconds(result.duration),
+			SystemOut: result.output,
+		}
+		if result.failed() {
+			suite.Failures++
+			tc.Failure = &junitMessage{Message: result.err.Error()}
+		} else if result.err != nil {
+			suite.Errors++
+			tc.Error = &junitMessage{Message: result.err.Error()}
+		}
+
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	for n := range suites.Suites {
+		var total time.Duration
+		for _, result := range results {
+			if result.file == suites.Suites[n].Name {
+				total += result.duration
+			}
+		}
+		suites.Suites[n].Time = seconds(total)
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	return encoder.Encode(suites)
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.6f", d.Seconds())
+}
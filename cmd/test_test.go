@@ -0,0 +1,73 @@
+package main
+
+import (
+	"GLox/internal/interpreter"
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunTestFile(t *testing.T) {
+	results, _ := runTestFile(filepath.Join(goldenDir, "test", "math_test.lox"))
+	if len(results) != 3 {
+		t.Fatalf("expected 3 tests, but got %d", len(results))
+	}
+
+	for _, result := range results {
+		if result.err != nil {
+			t.Errorf("%s: %v", result.name, result.err)
+		}
+	}
+}
+
+func TestTestReport(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "report_test.lox")
+	source := `
+test("passes", fun () { assert(true, "ok"); });
+test("fails", fun () { print "before"; assertEqual(1 + 1, 3); });
+test("errors", fun () { nil.field; });
+`
+	if err := ioutil.WriteFile(file, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, _ := runTestFile(file)
+	if len(results) != 3 {
+		t.Fatalf("expected 3 tests, but got %d", len(results))
+	}
+	if results[0].err != nil || !results[1].failed() || results[2].err == nil || results[2].failed() {
+		t.Fatalf("unexpected results: %v, %v, %v", results[0].err, results[1].err, results[2].err)
+	}
+
+	var text bytes.Buffer
+	writeTextReport(&text, results)
+	for _, want := range []string{"FAIL  " + file + ": fails", "expected 3, but got 2.", "| before", "3 tests, 1 passed, 2 failed"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text report is missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var junit bytes.Buffer
+	if err := writeJUnitReport(&junit, results); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`tests="3" failures="1" errors="1"`, `<testcase name="fails"`, "<failure message=", "<error message=", "<system-out>before"} {
+		if !strings.Contains(junit.String(), want) {
+			t.Errorf("JUnit report is missing %q:\n%s", want, junit.String())
+		}
+	}
+}
+
+func TestRegisteredTest(t *testing.T) {
+	tests := []interpreter.TestCase{{Name: "a"}, {Name: "b"}}
+	if tc, err := registeredTest(tests, 1, "b"); err != nil || tc.Name != "b" {
+		t.Errorf("got %v, %v, want test b", tc, err)
+	}
+	for _, n := range []int{0, 2} {
+		if _, err := registeredTest(tests, n, "b"); err == nil {
+			t.Errorf("%d: expected an error", n)
+		}
+	}
+}
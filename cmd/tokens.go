@@ -0,0 +1,91 @@
+package main
+
+import (
+	le "GLox/internal/loxerror"
+	"GLox/internal/scanner"
+	"GLox/internal/scanner/token"
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// tokenJSON 是 glox tokens -format json 输出的每一个token
+type tokenJSON struct {
+	Type    string      `json:"type"`
+	Lexeme  string      `json:"lexeme"`
+	Literal interface{} `json:"literal"`
+	Line    int         `json:"line"`
+	Column  int         `json:"column"`
+}
+
+// tokensCommand glox tokens [-format text|json] <file>，输出scanner扫描得到的token
+func tokensCommand(args []string) int {
+	fs := flag.NewFlagSet("tokens", flag.ExitOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: glox tokens [-format text|json] <file>")
+		return 2
+	}
+
+	bs, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	le.HadError = false
+	tokens := scanner.NewScanner(string(bs)).ScanTokens()
+
+	switch *format {
+	case "text":
+		err = writeTokensText(os.Stdout, tokens)
+	case "json":
+		err = writeTokensJSON(os.Stdout, tokens)
+	default:
+		err = fmt.Errorf("unknown format %q, expect text or json", *format)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	// 词法错误已经由scanner报告过了
+	if le.HadError {
+		return 1
+	}
+
+	return 0
+}
+
+// writeTokensText 每行输出一个token：行:列 类型 词素 字面量
+func writeTokensText(w io.Writer, tokens []*token.Token) error {
+	bw := bufio.NewWriter(w)
+	for _, t := range tokens {
+		line := fmt.Sprintf("%-8s %-14s %-12q", fmt.Sprintf("%d:%d", t.Line, t.Column), t.Type, t.Lexeme)
+		if t.Literal != nil {
+			line += fmt.Sprintf(" %#v", t.Literal)
+		}
+		fmt.Fprintln(bw, strings.TrimRight(line, " "))
+	}
+
+	return bw.Flush()
+}
+
+func writeTokensJSON(w io.Writer, tokens []*token.Token) error {
+	values := make([]tokenJSON, len(tokens))
+	for i, t := range tokens {
+		values[i] = tokenJSON{Type: t.Type.String(), Lexeme: t.Lexeme, Literal: t.Literal, Line: t.Line, Column: t.Column}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(values)
+}
@@ -0,0 +1,93 @@
+package interpreter
+
+import (
+	le "GLox/internal/loxerror"
+	"GLox/internal/scanner/token"
+	"fmt"
+)
+
+// assertionFailure 是assert系列native函数返回的错误，调用处会把它包装成带有行号的AssertionError
+type assertionFailure string
+
+func (a assertionFailure) Error() string {
+	return string(a)
+}
+
+// TestCase 是Lox脚本中通过 test(name, fn) 注册的测试
+type TestCase struct {
+	Name string
+	Fn   LoxCallable
+}
+
+// Tests 返回脚本执行过程中注册的所有测试，按注册的顺序排列
+func (i *Interpreter) Tests() []TestCase {
+	return i.tests
+}
+
+func defineAssertions(natives map[string]*Native) {
+	natives["test"] = NewLoxCallableImpl(registerTest, 2)
+	natives["assert"] = NewLoxCallableImpl(assert, 2)
+	natives["assertEqual"] = NewLoxCallableImpl(assertEqual, 2)
+	natives["assertThrows"] = NewLoxCallableImpl(assertThrows, 1)
+}
+
+func registerTest(interpreter *Interpreter, _ *token.Token, arguments []interface{}) (interface{}, error) {
+	name, ok := arguments[0].(string)
+	if !ok {
+		return nil, nativeError("test() expects a string as the test name.")
+	}
+
+	fn, ok := arguments[1].(LoxCallable)
+	if !ok || fn.Arity() != 0 {
+		return nil, nativeError("test() expects a function without parameters.")
+	}
+
+	interpreter.tests = append(interpreter.tests, TestCase{Name: name, Fn: fn})
+
+	return nil, nil
+}
+
+// assert(condition, message)
+func assert(_ *Interpreter, _ *token.Token, arguments []interface{}) (interface{}, error) {
+	if !isTruth(arguments[0]) {
+		return nil, assertionFailure(fmt.Sprintf("%v", arguments[1]))
+	}
+
+	return nil, nil
+}
+
+// assertEqual(actual, expected) 使用和 "==" 相同的语义比较两个值
+func assertEqual(interpreter *Interpreter, _ *token.Token, arguments []interface{}) (interface{}, error) {
+	operator := token.NewToken(token.EQUAL_EQUAL, "==", nil, 0)
+	equal, err := interpreter.equals(operator, arguments[0], arguments[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if !equal {
+		return nil, assertionFailure(fmt.Sprintf("expected %v, but got %v.", arguments[1], arguments[0]))
+	}
+
+	return nil, nil
+}
+
+// assertThrows(fn) 调用fn，只有它抛出RuntimeError的时候断言才成功。fn本身是native函数（比如g.next）时，
+// 它返回的错误也算作RuntimeError。断言失败、LimitError和CancelError不会被捕获，它们会结束整个测试或者脚本
+func assertThrows(interpreter *Interpreter, site *token.Token, arguments []interface{}) (interface{}, error) {
+	fn, ok := arguments[0].(LoxCallable)
+	if !ok || fn.Arity() != 0 {
+		return nil, nativeError("assertThrows() expects a function without parameters.")
+	}
+
+	_, err := fn.Call(interpreter, site, nil)
+	if err == nil {
+		return nil, assertionFailure("expected function to throw a runtime error.")
+	}
+
+	switch err.(type) {
+	case *le.RuntimeError, nativeError:
+		return nil, nil
+	}
+
+	return nil, err
+}
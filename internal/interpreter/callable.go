@@ -2,35 +2,58 @@ package interpreter
 
 import (
 	"GLox/internal/parser"
+	"GLox/internal/scanner/token"
+	"fmt"
 )
 
-type LoxCallableFunc func(interpreter *Interpreter, arguments []interface{}) interface{}
+type LoxCallableFunc func(interpreter *Interpreter, site *token.Token, arguments []interface{}) (interface{}, error)
 
 // LoxCallable 任何可以被调用的对象都要实现这个接口，比如定义的函数、类中的方法。
+// site是发起调用的token：调用表达式的 '('、getter和setter的属性名、重载的运算符等，
+// profiler用它统计调用位置。不是由代码发起的调用（比如 glox test 运行测试）传入nil
 type LoxCallable interface {
-	Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error)
+	Call(interpreter *Interpreter, site *token.Token, arguments []interface{}) (interface{}, error)
 	Arity() int
 }
 
 // ################ Native ###################
 
 type Native struct {
-	fn LoxCallableFunc
-	n  int
+	name     string // 注册到全局作用域时的名称
+	fn       LoxCallableFunc
+	n        int
+	optional int // 末尾可以省略的参数个数
 }
 
 func NewLoxCallableImpl(fn LoxCallableFunc, n int) *Native {
 	return &Native{fn: fn, n: n}
 }
 
-func (n *Native) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
-	return n.fn(interpreter, arguments), nil
+// withOptional 允许调用时省略最后count个参数，Arity仍然返回最多的参数个数
+func (n *Native) withOptional(count int) *Native {
+	n.optional = count
+	return n
+}
+
+func (n *Native) Call(interpreter *Interpreter, site *token.Token, arguments []interface{}) (interface{}, error) {
+	defer interpreter.profile(n.name, site)()
+
+	return n.fn(interpreter, site, arguments)
 }
 
 func (n *Native) Arity() int {
 	return n.n
 }
 
+// acceptsArguments 判断调用callee时传入count个参数是否合法
+func acceptsArguments(callee LoxCallable, count int) bool {
+	if native, ok := callee.(*Native); ok {
+		return count <= native.n && count >= native.n-native.optional
+	}
+
+	return count == callee.Arity()
+}
+
 func (n *Native) String() string {
 	return "<native fn>"
 }
@@ -47,7 +70,19 @@ func NewLoxFunction(declaration *parser.FuncDeclStmt, closure *Environment, isIn
 	return &LoxFunction{declaration: declaration, closure: closure, isInitializer: isInitializer}
 }
 
-func (lf *LoxFunction) Call(interpreter *Interpreter, arguments []interface{}) (result interface{}, err error) {
+func (lf *LoxFunction) Call(interpreter *Interpreter, site *token.Token, arguments []interface{}) (result interface{}, err error) {
+	// 含有yield的函数不会立即执行，而是返回一个generator
+	if lf.declaration.Generator {
+		return newGenerator(lf, lf.bindArguments(arguments)), nil
+	}
+
+	leave, err := interpreter.enterCall()
+	if err != nil {
+		return nil, err
+	}
+	defer leave()
+	defer interpreter.profile(lf.profileName(), site)()
+
 	// 捕获 return 语句
 	defer func() {
 		if r, ok := recover().(*Return); r != nil && ok {
@@ -59,14 +94,7 @@ func (lf *LoxFunction) Call(interpreter *Interpreter, arguments []interface{}) (
 		}
 	}()
 
-	// 创建函数自己的作用域
-	env := NewEnvironment(lf.closure)
-	// 将形参和实参绑定起来
-	for i, arg := range arguments {
-		env.define(lf.declaration.Params[i], arg)
-	}
-
-	err = interpreter.executeBlock(lf.declaration.Body, env)
+	err = interpreter.executeBlock(lf.declaration.Body, lf.bindArguments(arguments))
 	if err != nil {
 		return nil, err
 	}
@@ -74,6 +102,16 @@ func (lf *LoxFunction) Call(interpreter *Interpreter, arguments []interface{}) (
 	return result, nil
 }
 
+// bindArguments 创建函数自己的作用域，将形参和实参绑定起来
+func (lf *LoxFunction) bindArguments(arguments []interface{}) *Environment {
+	env := NewEnvironment(lf.closure)
+	for i, arg := range arguments {
+		env.define(lf.declaration.Params[i], arg)
+	}
+
+	return env
+}
+
 // bind an instance for the method.
 func (lf *LoxFunction) bind(instance *LoxInstance) *LoxFunction {
 	environment := NewEnvironment(lf.closure)
@@ -86,6 +124,19 @@ func (lf *LoxFunction) Arity() int {
 	return len(lf.declaration.Params)
 }
 
+// profileName 是函数在profiler中的名称，包含了函数声明所在的行
+func (lf *LoxFunction) profileName() string {
+	if lf.declaration.Name == nil {
+		return fmt.Sprintf("<lambda>:%d", lf.declaration.Line())
+	}
+
+	return fmt.Sprintf("%s:%d", lf.declaration.Name.Lexeme, lf.declaration.Name.Line)
+}
+
 func (lf *LoxFunction) String() string {
+	if lf.declaration.Name == nil {
+		return "<fn anonymous>"
+	}
+
 	return "<fn " + lf.declaration.Name.Lexeme + ">"
 }
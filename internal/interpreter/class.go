@@ -1,23 +1,47 @@
 package interpreter
 
+import (
+	"GLox/internal/loxerror"
+	"GLox/internal/scanner/token"
+)
+
 // LoxClass implements LoxCallable, specific the constructor method.
 type LoxClass struct {
-	name       string
-	superclass *LoxClass
-	methods    map[string]*LoxFunction
+	name         string
+	superclass   *LoxClass
+	traits       []*LoxTrait
+	methods      map[string]*LoxFunction
+	classMethods map[string]*LoxFunction // static methods, called on the class itself
+	getters      map[string]*LoxFunction
+	setters      map[string]*LoxFunction
+	fields       map[string]interface{} // class-level constants
 }
 
 func NewLoxClass(name string, superclass *LoxClass, methods map[string]*LoxFunction) *LoxClass {
-	return &LoxClass{name: name, superclass: superclass, methods: methods}
+	return &LoxClass{
+		name:         name,
+		superclass:   superclass,
+		methods:      methods,
+		classMethods: make(map[string]*LoxFunction),
+		getters:      make(map[string]*LoxFunction),
+		setters:      make(map[string]*LoxFunction),
+		fields:       make(map[string]interface{}),
+	}
 }
 
 // Call means "constructor", e.g. class Foo {}; print(Foo());
-func (lc *LoxClass) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+func (lc *LoxClass) Call(interpreter *Interpreter, site *token.Token, arguments []interface{}) (interface{}, error) {
+	defer interpreter.profile(lc.name, site)()
+
+	if err := interpreter.allocate(instanceSize); err != nil {
+		return nil, err
+	}
+
 	instance := NewLoxInstance(lc)
-	// init() will be called when an instance is initialized
-	if initializer, ok := lc.methods["init"]; ok {
+	// init() will be called when an instance is initialized, it may be inherited from the superclass
+	if initializer := lc.findMethod("init"); initializer != nil {
 		// 类中的方法首先要经过bind处理，为特殊变量this绑定值
-		_, err := initializer.bind(instance).Call(interpreter, arguments)
+		_, err := initializer.bind(instance).Call(interpreter, site, arguments)
 		if err != nil {
 			return nil, err
 		}
@@ -27,13 +51,28 @@ func (lc *LoxClass) Call(interpreter *Interpreter, arguments []interface{}) (int
 }
 
 func (lc *LoxClass) Arity() int {
-	if initializer, ok := lc.methods["init"]; ok {
+	if initializer := lc.findMethod("init"); initializer != nil {
 		return initializer.Arity()
 	}
 
 	return 0
 }
 
+// Get looks up class-level constants first, then static methods, e.g. Math.PI, Math.square(2).
+func (lc *LoxClass) Get(attribute *token.Token) (interface{}, error) {
+	for class := lc; class != nil; class = class.superclass {
+		if field, ok := class.fields[attribute.Lexeme]; ok {
+			return field, nil
+		}
+
+		if method, ok := class.classMethods[attribute.Lexeme]; ok {
+			return method, nil
+		}
+	}
+
+	return nil, loxerror.NewRuntimeError(attribute, "undefined class attribute '"+attribute.Lexeme+"'.")
+}
+
 func (lc *LoxClass) findMethod(name string) *LoxFunction {
 	if method, ok := lc.methods[name]; ok {
 		return method
@@ -46,6 +85,36 @@ func (lc *LoxClass) findMethod(name string) *LoxFunction {
 	return nil
 }
 
+func (lc *LoxClass) findGetter(name string) *LoxFunction {
+	for class := lc; class != nil; class = class.superclass {
+		if getter, ok := class.getters[name]; ok {
+			return getter
+		}
+	}
+
+	return nil
+}
+
+func (lc *LoxClass) findSetter(name string) *LoxFunction {
+	for class := lc; class != nil; class = class.superclass {
+		if setter, ok := class.setters[name]; ok {
+			return setter
+		}
+	}
+
+	return nil
+}
+
+func (lc *LoxClass) findField(name string) (interface{}, bool) {
+	for class := lc; class != nil; class = class.superclass {
+		if field, ok := class.fields[name]; ok {
+			return field, true
+		}
+	}
+
+	return nil, false
+}
+
 func (lc *LoxClass) String() string {
 	if lc.superclass != nil {
 		return "<class " + lc.name + " inherit " + lc.superclass.name + ">"
@@ -0,0 +1,51 @@
+package interpreter_test
+
+import (
+	le "GLox/internal/loxerror"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInterpretContext(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		line   int
+	}{
+		{"while", "var n = 0;\nwhile (true) {\n  n = n + 1;\n}", 2},
+		{"for", "for (var n = 0; ; n = n + 1) {}", 1},
+		{"call", "fun f() {\n  return f();\n}\nf();", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, stmts := prepare(t, tt.source)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(10*time.Millisecond, cancel)
+
+			err := i.InterpretContext(ctx, stmts)
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected an error wrapping context.Canceled, but got %v", err)
+			}
+
+			var cancelErr *le.CancelError
+			if !errors.As(err, &cancelErr) || cancelErr.Line != tt.line {
+				t.Errorf("expected execution to stop at line %d, but got %v", tt.line, err)
+			}
+		})
+	}
+}
+
+func TestInterpretContextCompleted(t *testing.T) {
+	i, stmts := prepare(t, "var n = 0; while (n < 10) { n = n + 1; }")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := i.InterpretContext(ctx, stmts); err != nil {
+		t.Fatal(err)
+	}
+}
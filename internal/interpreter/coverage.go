@@ -0,0 +1,233 @@
+package interpreter
+
+import (
+	parser2 "GLox/internal/parser"
+	"sort"
+)
+
+// Coverage 记录一个Lox文件中每一行statement的执行次数，以及if语句和 and/or 表达式每个分支的执行次数。
+// 同一个文件可能被多次解析执行（比如glox test中的每个测试），所以结果按行号而不是语法树节点保存
+type Coverage struct {
+	File   string
+	Source string
+
+	lines    map[int]int // 行号 -> 执行次数，0表示没有执行过
+	branches map[BranchKey]*[2]int
+	nodes    map[interface{}]BranchKey // IfStmt和Logic节点 -> 对应的分支
+}
+
+// BranchKey 定位一个分支点，Block是该行中第几个分支点（从0开始）
+type BranchKey struct {
+	Line  int
+	Block int
+}
+
+func NewCoverage(file, source string) *Coverage {
+	return &Coverage{
+		File:     file,
+		Source:   source,
+		lines:    make(map[int]int),
+		branches: make(map[BranchKey]*[2]int),
+		nodes:    make(map[interface{}]BranchKey),
+	}
+}
+
+// SetCoverage 之后执行的statement和分支都会记录到coverage中，nil表示关闭
+func (i *Interpreter) SetCoverage(coverage *Coverage) {
+	i.coverage = coverage
+}
+
+// branch 在开启了coverage的时候记录分支的执行
+func (i *Interpreter) branch(node interface{}, n int) {
+	if i.coverage != nil {
+		i.coverage.branch(node, n)
+	}
+}
+
+// Register 在执行之前遍历语法树，登记所有可以执行的行和分支点，这样没有执行过的行也会出现在报告中
+func (c *Coverage) Register(stmts []parser2.Stmt) {
+	r := &registrar{coverage: c, blocks: make(map[int]int)}
+	r.stmts(stmts)
+}
+
+func (c *Coverage) hit(stmt parser2.Stmt) {
+	if _, ok := stmt.(*parser2.BlockStmt); ok {
+		return
+	}
+
+	if line := stmt.Line(); line > 0 {
+		c.lines[line]++
+	}
+}
+
+// branch 记录node的第n个分支被执行了一次。if语句的分支0是then，1是else；
+// and/or 表达式的分支0是短路，1是计算了右侧的表达式
+func (c *Coverage) branch(node interface{}, n int) {
+	if key, ok := c.nodes[node]; ok {
+		c.branches[key][n]++
+	}
+}
+
+// Lines 返回所有可以执行的行号，按从小到大排列
+func (c *Coverage) Lines() []int {
+	var lines []int
+	for line := range c.lines {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+
+	return lines
+}
+
+// Hits 返回一行代码的执行次数
+func (c *Coverage) Hits(line int) int {
+	return c.lines[line]
+}
+
+// Branches 返回所有的分支点，按位置排列
+func (c *Coverage) Branches() []BranchKey {
+	var keys []BranchKey
+	for key := range c.branches {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].Line != keys[b].Line {
+			return keys[a].Line < keys[b].Line
+		}
+
+		return keys[a].Block < keys[b].Block
+	})
+
+	return keys
+}
+
+// BranchHits 返回一个分支点的两个分支各自的执行次数
+func (c *Coverage) BranchHits(key BranchKey) [2]int {
+	return *c.branches[key]
+}
+
+// LineRate 返回执行过的行数和可以执行的总行数
+func (c *Coverage) LineRate() (hit, total int) {
+	for _, hits := range c.lines {
+		total++
+		if hits > 0 {
+			hit++
+		}
+	}
+
+	return hit, total
+}
+
+// BranchRate 返回执行过的分支数和总的分支数，每个分支点有两个分支
+func (c *Coverage) BranchRate() (hit, total int) {
+	for _, hits := range c.branches {
+		for _, n := range hits {
+			total++
+			if n > 0 {
+				hit++
+			}
+		}
+	}
+
+	return hit, total
+}
+
+// registrar 遍历语法树，为Coverage登记行和分支点
+type registrar struct {
+	coverage *Coverage
+	blocks   map[int]int // 行号 -> 该行已经登记的分支点个数
+}
+
+func (r *registrar) stmts(stmts []parser2.Stmt) {
+	for _, stmt := range stmts {
+		r.stmt(stmt)
+	}
+}
+
+func (r *registrar) stmt(stmt parser2.Stmt) {
+	if stmt == nil {
+		return
+	}
+
+	// block本身不算作一行代码，它里面的statement才是
+	if _, ok := stmt.(*parser2.BlockStmt); !ok && stmt.Line() > 0 {
+		if _, ok := r.coverage.lines[stmt.Line()]; !ok {
+			r.coverage.lines[stmt.Line()] = 0
+		}
+	}
+
+	switch s := stmt.(type) {
+	case *parser2.ExprStmt:
+		r.expr(s.Expr)
+	case *parser2.PrintStmt:
+		r.expr(s.Expr)
+	case *parser2.VarDeclStmt:
+		r.expr(s.Initializer)
+	case *parser2.ReturnStmt:
+		r.expr(s.Value)
+	case *parser2.YieldStmt:
+		r.expr(s.Value)
+	case *parser2.BlockStmt:
+		r.stmts(s.Stmts)
+	case *parser2.IfStmt:
+		r.addBranch(s, s.Keyword.Line)
+		r.expr(s.Condition)
+		r.stmt(s.ThenBranch)
+		r.stmt(s.ElseBranch)
+	case *parser2.WhileStmt:
+		r.expr(s.Condition)
+		r.stmt(s.Body)
+	case *parser2.ForInStmt:
+		r.expr(s.Iterable)
+		r.stmt(s.Body)
+	case *parser2.FuncDeclStmt:
+		r.function(s)
+	case *parser2.TraitDeclStmt:
+		r.functions(s.Methods)
+	case *parser2.ClassDeclStmt:
+		// 类级别的常量不是通过execute执行的，只登记它们的初始化表达式
+		for _, field := range s.Fields {
+			r.expr(field.Initializer)
+		}
+		r.functions(s.Methods)
+		r.functions(s.ClassMethods)
+		r.functions(s.Getters)
+		r.functions(s.Setters)
+	}
+}
+
+func (r *registrar) function(function *parser2.FuncDeclStmt) {
+	r.stmts(function.Body.Stmts)
+}
+
+func (r *registrar) functions(functions []*parser2.FuncDeclStmt) {
+	for _, function := range functions {
+		r.function(function)
+	}
+}
+
+func (r *registrar) expr(expr parser2.Expr) {
+	parser2.Walk(expr, func(node parser2.Node) bool {
+		switch e := node.(type) {
+		case *parser2.Logic:
+			r.addBranch(e, e.Operator.Line)
+		case *parser2.Conditional:
+			r.addBranch(e, e.Question.Line)
+		case *parser2.Lambda:
+			// lambda的函数体是statement，需要登记行号
+			r.function(e.Function)
+			return false
+		}
+		return true
+	})
+}
+
+func (r *registrar) addBranch(node interface{}, line int) {
+	key := BranchKey{Line: line, Block: r.blocks[line]}
+	r.blocks[line]++
+
+	r.coverage.nodes[node] = key
+	if _, ok := r.coverage.branches[key]; !ok {
+		r.coverage.branches[key] = new([2]int)
+	}
+}
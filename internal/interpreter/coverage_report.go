@@ -0,0 +1,123 @@
+package interpreter
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// WriteLCOV 以LCOV的tracefile格式输出多个文件的覆盖率，genhtml等工具可以直接读取
+func WriteLCOV(w io.Writer, coverages []*Coverage) error {
+	bw := bufio.NewWriter(w)
+	for _, c := range coverages {
+		fmt.Fprintf(bw, "TN:\nSF:%s\n", c.File)
+
+		for _, key := range c.Branches() {
+			for n, taken := range c.BranchHits(key) {
+				// 分支所在的行没有执行过的时候，taken用"-"表示
+				if c.Hits(key.Line) == 0 {
+					fmt.Fprintf(bw, "BRDA:%d,%d,%d,-\n", key.Line, key.Block, n)
+				} else {
+					fmt.Fprintf(bw, "BRDA:%d,%d,%d,%d\n", key.Line, key.Block, n, taken)
+				}
+			}
+		}
+		hit, total := c.BranchRate()
+		fmt.Fprintf(bw, "BRF:%d\nBRH:%d\n", total, hit)
+
+		for _, line := range c.Lines() {
+			fmt.Fprintf(bw, "DA:%d,%d\n", line, c.Hits(line))
+		}
+		hit, total = c.LineRate()
+		fmt.Fprintf(bw, "LF:%d\nLH:%d\n", total, hit)
+
+		fmt.Fprintln(bw, "end_of_record")
+	}
+
+	return bw.Flush()
+}
+
+var htmlReport = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.File}}</title>
+<style>
+body { font-family: monospace; }
+table { border-collapse: collapse; }
+td { padding: 0 8px; white-space: pre; }
+.number, .hits { color: #888; text-align: right; }
+.covered { background: #dfd; }
+.uncovered { background: #fdd; }
+.partial { background: #ffd; }
+</style>
+</head>
+<body>
+<h1>{{.File}}</h1>
+<p>Lines: {{.LinesHit}}/{{.LinesTotal}} ({{printf "%.1f" .LinePercent}}%), Branches: {{.BranchesHit}}/{{.BranchesTotal}} ({{printf "%.1f" .BranchPercent}}%)</p>
+<table>
+{{range .Lines}}<tr class="{{.Class}}"><td class="number">{{.Number}}</td><td class="hits">{{.Hits}}</td><td>{{.Text}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+type htmlLine struct {
+	Number int
+	Hits   string
+	Class  string
+	Text   string
+}
+
+// WriteHTML 输出一个文件的HTML报告，执行过的行为绿色，没有执行过的为红色，有分支没有执行过的为黄色
+func (c *Coverage) WriteHTML(w io.Writer) error {
+	partial := make(map[int]bool)
+	for _, key := range c.Branches() {
+		hits := c.BranchHits(key)
+		if hits[0] == 0 || hits[1] == 0 {
+			partial[key.Line] = true
+		}
+	}
+
+	var lines []htmlLine
+	for n, text := range strings.Split(c.Source, "\n") {
+		line := htmlLine{Number: n + 1, Text: text}
+		if hits, ok := c.lines[line.Number]; ok {
+			line.Hits = fmt.Sprint(hits)
+			switch {
+			case hits == 0:
+				line.Class = "uncovered"
+			case partial[line.Number]:
+				line.Class = "partial"
+			default:
+				line.Class = "covered"
+			}
+		}
+		lines = append(lines, line)
+	}
+
+	linesHit, linesTotal := c.LineRate()
+	branchesHit, branchesTotal := c.BranchRate()
+
+	return htmlReport.Execute(w, map[string]interface{}{
+		"File":          c.File,
+		"Lines":         lines,
+		"LinesHit":      linesHit,
+		"LinesTotal":    linesTotal,
+		"LinePercent":   percent(linesHit, linesTotal),
+		"BranchesHit":   branchesHit,
+		"BranchesTotal": branchesTotal,
+		"BranchPercent": percent(branchesHit, branchesTotal),
+	})
+}
+
+// percent 没有可以统计的内容时视为全部覆盖
+func percent(hit, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+
+	return float64(hit) * 100 / float64(total)
+}
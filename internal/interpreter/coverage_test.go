@@ -0,0 +1,55 @@
+package interpreter_test
+
+import (
+	"GLox/internal/interpreter"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	source := `fun abs(n) {
+    if (n < 0) {
+        return -n;
+    }
+    return n;
+}
+var a = abs(3);
+var b = a > 1 or a < -1;`
+
+	i, stmts := prepare(t, source)
+	coverage := interpreter.NewCoverage("abs.lox", source)
+	coverage.Register(stmts)
+	i.SetCoverage(coverage)
+	if err := i.Interpret(stmts); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[int]int{1: 1, 2: 1, 3: 0, 5: 1, 7: 1, 8: 1}
+	lines := coverage.Lines()
+	if len(lines) != len(expected) {
+		t.Fatalf("expected lines %v, but got %v", expected, lines)
+	}
+	for _, line := range lines {
+		if coverage.Hits(line) != expected[line] {
+			t.Errorf("line %d: expected %d hits, but got %d", line, expected[line], coverage.Hits(line))
+		}
+	}
+
+	branches := map[interpreter.BranchKey][2]int{{Line: 2}: {0, 1}, {Line: 8}: {1, 0}}
+	for key, hits := range branches {
+		if coverage.BranchHits(key) != hits {
+			t.Errorf("branch %v: expected %v, but got %v", key, hits, coverage.BranchHits(key))
+		}
+	}
+
+	var lcov bytes.Buffer
+	if err := interpreter.WriteLCOV(&lcov, []*interpreter.Coverage{coverage}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"SF:abs.lox\n", "DA:3,0\n", "BRDA:2,0,0,0\n", "BRDA:8,0,0,1\n", "LF:6\nLH:5\n", "BRF:4\nBRH:2\n"} {
+		if !strings.Contains(lcov.String(), want) {
+			t.Errorf("LCOV report is missing %q:\n%s", want, lcov.String())
+		}
+	}
+}
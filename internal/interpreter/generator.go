@@ -0,0 +1,250 @@
+package interpreter
+
+import (
+	"GLox/internal/loxerror"
+	"GLox/internal/scanner/token"
+)
+
+// LoxGenerator 是调用含有yield的函数得到的值，函数体在单独的goroutine中执行，每次yield之后挂起，
+// 直到下一次next()或者hasNext()。同一时刻只有调用者或者生成器中的一方在运行，两者通过channel交接，
+// 所以它们可以共用一个Interpreter，交接的时候互相保存和恢复作用域等状态（见generatorFrame）。
+//
+// 没有执行完的生成器在提前退出的for-in循环和Interpret结束时被close，它的goroutine从yield处退出
+type LoxGenerator struct {
+	function *LoxFunction
+	env      *Environment // 绑定了参数的函数作用域
+
+	resume  chan struct{}
+	closing chan struct{} // close之后，挂起在yield的goroutine不再等待resume
+	results chan generatorResult
+
+	started, running, done bool
+	buffered               *generatorResult // hasNext()提前取出、还没有被next()返回的结果
+}
+
+// generatorResult 是生成器每次交还控制权时的结果，done为true时函数已经执行完毕，value没有意义
+type generatorResult struct {
+	value interface{}
+	done  bool
+	err   error
+	panic interface{} // 函数体中除了Return之外的panic，交给调用者的goroutine重新panic
+}
+
+// generatorFrame 是调用者和生成器切换时需要保存的解释器状态
+type generatorFrame struct {
+	environment *Environment
+	depth       int
+	generator   *LoxGenerator
+}
+
+func (i *Interpreter) saveFrame() generatorFrame {
+	return generatorFrame{environment: i.environment, depth: i.depth, generator: i.generator}
+}
+
+func (i *Interpreter) restoreFrame(f generatorFrame) {
+	i.environment, i.depth, i.generator = f.environment, f.depth, f.generator
+}
+
+func newGenerator(function *LoxFunction, env *Environment) *LoxGenerator {
+	return &LoxGenerator{
+		function: function,
+		env:      env,
+		resume:   make(chan struct{}),
+		closing:  make(chan struct{}),
+		results:  make(chan generatorResult),
+	}
+}
+
+// step 运行生成器直到下一个yield或者函数结束，site是调用next()、hasNext()或者for-in循环的位置
+func (g *LoxGenerator) step(i *Interpreter, site *token.Token) generatorResult {
+	if g.done {
+		return generatorResult{done: true}
+	}
+	if g.running {
+		return generatorResult{err: nativeError("Generator is already running.")}
+	}
+	defer i.profile(g.function.profileName(), site)()
+
+	caller := i.saveFrame()
+	i.generator = g
+	g.running = true
+	if g.started {
+		g.resume <- struct{}{}
+	} else {
+		g.started = true
+		i.suspended(g, true)
+		go g.run(i)
+	}
+
+	result := <-g.results
+	g.running = false
+	i.restoreFrame(caller)
+
+	if result.done || result.err != nil {
+		g.done = true
+		i.suspended(g, false)
+	}
+	if result.panic != nil {
+		panic(result.panic)
+	}
+
+	return result
+}
+
+// run 在生成器的goroutine中执行函数体，最后一次交还控制权之前所有修改解释器状态的defer都已经执行完
+func (g *LoxGenerator) run(i *Interpreter) {
+	g.results <- g.body(i)
+}
+
+func (g *LoxGenerator) body(i *Interpreter) (result generatorResult) {
+	defer func() {
+		// return语句结束生成器，它的值已经被resolver禁止了
+		if r := recover(); r != nil {
+			if _, ok := r.(*Return); !ok {
+				result.panic = r
+			}
+		}
+		result.done = true
+	}()
+
+	leave, err := i.enterCall()
+	if err != nil {
+		return generatorResult{err: err}
+	}
+	defer leave()
+
+	return generatorResult{err: i.executeBlock(g.function.declaration.Body, g.env)}
+}
+
+// yield 在生成器的goroutine中调用，把value交给调用者，然后等待下一次step
+func (g *LoxGenerator) yield(i *Interpreter, value interface{}) {
+	suspended := i.saveFrame()
+	g.results <- generatorResult{value: value}
+	select {
+	case <-g.resume:
+	case <-g.closing:
+		panic(generatorClosed{})
+	}
+	i.restoreFrame(suspended)
+}
+
+// generatorClosed 是close时yield抛出的panic，函数体中的defer依次执行之后由body捕获
+type generatorClosed struct{}
+
+// close 结束挂起的生成器，等它的goroutine退出之后才返回，之后的next()和hasNext()会认为生成器已经结束。
+// 正在运行的生成器不能被close
+func (g *LoxGenerator) close(i *Interpreter) {
+	if g.done || g.running {
+		return
+	}
+	g.done, g.buffered = true, nil
+	if !g.started {
+		return
+	}
+
+	// 函数体中的defer会修改解释器的作用域和调用深度，等它们执行完之后再恢复调用者的状态
+	caller := i.saveFrame()
+	close(g.closing)
+	<-g.results
+	i.restoreFrame(caller)
+	i.suspended(g, false)
+}
+
+// suspended 记录已经开始、还没有结束的生成器，InterpretContext返回之前把它们都close掉
+func (i *Interpreter) suspended(g *LoxGenerator, live bool) {
+	if !live {
+		delete(i.live, g)
+		return
+	}
+	if i.live == nil {
+		i.live = make(map[*LoxGenerator]struct{})
+	}
+	i.live[g] = struct{}{}
+}
+
+// closeGenerators close所有没有执行完的生成器
+func (i *Interpreter) closeGenerators() {
+	for g := range i.live {
+		g.close(i)
+	}
+}
+
+// next 返回下一个yield的值，生成器结束之后再调用是一个错误
+func (g *LoxGenerator) next(i *Interpreter, site *token.Token) (interface{}, error) {
+	result := g.take(i, site)
+	if result.err != nil {
+		return nil, result.err
+	}
+	if result.done {
+		return nil, nativeError("Generator is exhausted.")
+	}
+
+	return result.value, nil
+}
+
+// hasNext 需要运行到下一个yield才能知道是否还有值，取出的值留给下一次next
+func (g *LoxGenerator) hasNext(i *Interpreter, site *token.Token) (bool, error) {
+	if g.buffered == nil {
+		result := g.step(i, site)
+		if result.err != nil {
+			return false, result.err
+		}
+		g.buffered = &result
+	}
+
+	return !g.buffered.done, nil
+}
+
+func (g *LoxGenerator) take(i *Interpreter, site *token.Token) generatorResult {
+	if g.buffered != nil {
+		result := *g.buffered
+		g.buffered = nil
+		return result
+	}
+
+	return g.step(i, site)
+}
+
+// Get 返回生成器的next和hasNext方法
+func (g *LoxGenerator) Get(attribute *token.Token) (interface{}, error) {
+	var method *Native
+	switch attribute.Lexeme {
+	case nextMethod:
+		method = NewLoxCallableImpl(func(i *Interpreter, site *token.Token, _ []interface{}) (interface{}, error) {
+			return g.next(i, site)
+		}, 0)
+	case hasNextMethod:
+		method = NewLoxCallableImpl(func(i *Interpreter, site *token.Token, _ []interface{}) (interface{}, error) {
+			return g.hasNext(i, site)
+		}, 0)
+	default:
+		return nil, loxerror.NewRuntimeError(attribute, "undefined attribute '"+attribute.Lexeme+"'.")
+	}
+	method.name = attribute.Lexeme
+
+	return method, nil
+}
+
+func (g *LoxGenerator) String() string {
+	if g.function.declaration.Name == nil {
+		return "<generator anonymous>"
+	}
+
+	return "<generator " + g.function.declaration.Name.Lexeme + ">"
+}
+
+// generatorIterator 让for-in循环直接遍历生成器，不需要通过Get得到方法
+type generatorIterator struct {
+	at        *token.Token
+	generator *LoxGenerator
+}
+
+func (it *generatorIterator) next(i *Interpreter) (interface{}, bool, error) {
+	result := it.generator.take(i, it.at)
+
+	return result.value, !result.done && result.err == nil, result.err
+}
+
+func (it *generatorIterator) close(i *Interpreter) {
+	it.generator.close(i)
+}
@@ -0,0 +1,111 @@
+package interpreter_test
+
+import (
+	"GLox/internal/interpreter"
+	le "GLox/internal/loxerror"
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGeneratorErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		err    string
+	}{
+		{"exhausted", "fun g() { yield 1; }\nvar it = g(); it.next();\nit.next();", "line 3 : Generator is exhausted."},
+		{"already running", "var it;\nfun g() {\n  yield it.next();\n}\nit = g(); it.next();", "line 3 : Generator is already running."},
+		{"error in body", "fun g() {\n  yield 1;\n  yield -nil;\n}\nfor (x in g()) {}", "line 3 : Operand must be a number."},
+		{"undefined attribute", "fun g() { yield 1; }\ng().send(1);", "line 2 : undefined attribute 'send'."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, stmts := prepare(t, tt.source)
+			if err := i.Interpret(stmts); err == nil || !strings.HasSuffix(err.Error(), tt.err) {
+				t.Errorf("got %v, want an error ending with %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// 挂起的生成器不占用调用者的调用深度
+func TestGeneratorCallDepth(t *testing.T) {
+	source := `
+fun g() {
+  yield 1;
+  yield 2;
+}
+var generators = [g(), g(), g(), g(), g(), g(), g(), g()];
+for (it in generators) it.next();
+for (it in generators) it.next();`
+
+	i, stmts := prepare(t, source)
+	i.SetLimits(interpreter.Limits{MaxCallDepth: 3})
+	if err := i.Interpret(stmts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGeneratorContext(t *testing.T) {
+	i, stmts := prepare(t, "fun g() {\n  while (true) {}\n  yield 1;\n}\ng().next();")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	err := i.InterpretContext(ctx, stmts)
+	var cancelErr *le.CancelError
+	if !errors.As(err, &cancelErr) || cancelErr.Line != 2 {
+		t.Errorf("expected execution to stop at line 2, but got %v", err)
+	}
+}
+
+// 没有执行完的生成器在Interpret结束时被close，它们的goroutine不会泄漏
+func TestGeneratorClose(t *testing.T) {
+	source := `
+fun g() {
+  var n = 0;
+  while (true) yield n = n + 1;
+}
+var it = g();
+it.next();`
+
+	before := runtime.NumGoroutine()
+	for n := 0; n < 50; n++ {
+		i, stmts := prepare(t, source)
+		if err := i.Interpret(stmts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 退出的goroutine可能还没有被回收
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("got %d goroutines, want at most %d", after, before)
+	}
+}
+
+// 提前退出的for-in循环会结束它遍历的生成器
+func TestGeneratorCloseForIn(t *testing.T) {
+	tests := map[string]string{
+		"return": "fun first() {\n  for (x in it) return x;\n}\nfirst();",
+		"error":  "assertThrows(fun () {\n  for (x in it) -nil;\n});",
+	}
+
+	for name, loop := range tests {
+		t.Run(name, func(t *testing.T) {
+			source := "fun g() {\n  yield 1;\n  yield 2;\n}\nvar it = g();\n" + loop + "\nit.next();"
+			i, stmts := prepare(t, source)
+			if err := i.Interpret(stmts); err == nil || !strings.HasSuffix(err.Error(), "Generator is exhausted.") {
+				t.Errorf("got %v, want the generator to be exhausted", err)
+			}
+		})
+	}
+}
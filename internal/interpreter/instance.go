@@ -14,20 +14,62 @@ func NewLoxInstance(class *LoxClass) *LoxInstance {
 	return &LoxInstance{class: class, fields: make(map[string]interface{})}
 }
 
-// Get will first look for fields defined in the class, then methods.
-func (ls *LoxInstance) Get(attribute *token.Token) (interface{}, error) {
+// Get will first look for fields defined in the instance, then getters, methods and class-level constants.
+func (ls *LoxInstance) Get(interpreter *Interpreter, attribute *token.Token) (interface{}, error) {
 	if field, ok := ls.fields[attribute.Lexeme]; ok {
 		return field, nil
 	}
 
+	// getter在访问属性的时候直接执行
+	if getter := ls.class.findGetter(attribute.Lexeme); getter != nil {
+		if value, ok, err := interpreter.inlineGetter(ls, getter); ok {
+			return value, err
+		}
+		return getter.bind(ls).Call(interpreter, attribute, nil)
+	}
+
 	if method := ls.class.findMethod(attribute.Lexeme); method != nil {
 		return method.bind(ls), nil
 	}
 
+	if field, ok := ls.class.findField(attribute.Lexeme); ok {
+		return field, nil
+	}
+
 	//panic(loxerror.NewRuntimeError(attribute, "undefined attribute '"+attribute.Lexeme+"'."))
 	return nil, loxerror.NewRuntimeError(attribute, "undefined attribute '"+attribute.Lexeme+"'.")
 }
 
+// Has reports whether Get would find the attribute.
+func (ls *LoxInstance) Has(name string) bool {
+	if _, ok := ls.fields[name]; ok {
+		return true
+	}
+
+	if _, ok := ls.class.findField(name); ok {
+		return true
+	}
+
+	return ls.class.findGetter(name) != nil || ls.class.findMethod(name) != nil
+}
+
+// Set calls the setter if the class defines one, otherwise stores the value as a field.
+func (ls *LoxInstance) Set(interpreter *Interpreter, attribute *token.Token, value interface{}) error {
+	if setter := ls.class.findSetter(attribute.Lexeme); setter != nil {
+		_, err := setter.bind(ls).Call(interpreter, attribute, []interface{}{value})
+		return err
+	}
+
+	if _, ok := ls.fields[attribute.Lexeme]; !ok {
+		if err := interpreter.allocate(fieldSize + len(attribute.Lexeme)); err != nil {
+			return err
+		}
+	}
+	ls.fields[attribute.Lexeme] = value
+
+	return nil
+}
+
 func (ls *LoxInstance) String() string {
 	return "<" + ls.class.name + " instance>"
 }
@@ -0,0 +1,157 @@
+package interpreter
+
+import (
+	le "GLox/internal/loxerror"
+	"GLox/internal/numeric"
+	"GLox/internal/scanner/token"
+	"math"
+	"math/big"
+)
+
+// maxShift 限制任意精度整数左移的位数，避免 1 << 1e9 占用大量内存
+const maxShift = 1 << 16
+
+// toInteger 把整数值的数字转换成int64，小数、超出int64范围的数字和其他类型的值都会报错
+func toInteger(operator *token.Token, operand interface{}) (int64, error) {
+	n, ok := operand.(float64)
+	if !ok || n != math.Trunc(n) || math.Abs(n) >= math.MaxInt64 {
+		return 0, le.NewRuntimeError(operator, "Operand must be an integer.")
+	}
+
+	return int64(n), nil
+}
+
+// toBigInteger 和toInteger一样，但是接受所有整数值的数字
+func toBigInteger(operator *token.Token, operand interface{}) (*big.Int, error) {
+	n, ok := numeric.Int(operand)
+	if !ok {
+		return nil, le.NewRuntimeError(operator, "Operand must be an integer.")
+	}
+
+	return n, nil
+}
+
+// integerBinary 计算取模、位运算和移位，两个操作数都必须是整数。
+// 两个操作数都是float64时结果仍然是float64，否则结果是任意精度的整数
+func integerBinary(operator *token.Token, lv, rv interface{}) (interface{}, error) {
+	_, lf := lv.(float64)
+	_, rf := rv.(float64)
+	if !lf || !rf {
+		return bigIntegerBinary(operator, lv, rv)
+	}
+
+	l, err := toInteger(operator, lv)
+	if err != nil {
+		return nil, err
+	}
+	r, err := toInteger(operator, rv)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operator.Type {
+	case token.PERCENT:
+		if r == 0 {
+			return nil, le.NewRuntimeError(operator, "Division by zero.")
+		}
+		return exactFloat(operator, l%r)
+	case token.AMPERSAND:
+		return exactFloat(operator, l&r)
+	case token.PIPE:
+		return exactFloat(operator, l|r)
+	case token.CARET:
+		return exactFloat(operator, l^r)
+	case token.LESS_LESS:
+		if r < 0 {
+			return nil, le.NewRuntimeError(operator, "Negative shift count.")
+		}
+		// 移出了有效位的时候（包括 1 << 64 这种结果为0的情况）一定超出了范围
+		if l != 0 && (r >= 63 || l<<uint64(r)>>uint64(r) != l) {
+			return nil, errNotExact(operator)
+		}
+		return exactFloat(operator, l<<uint64(r))
+	case token.GREATER_GREATER:
+		if r < 0 {
+			return nil, le.NewRuntimeError(operator, "Negative shift count.")
+		}
+		// 算术右移，负数的符号位保持不变
+		return exactFloat(operator, l>>uint64(r))
+	}
+
+	return nil, nil
+}
+
+// exactFloat 把float模式下整数运算的结果转换成float64，超出 ±2^53 的结果不能精确表示，报错而不是丢失精度
+func exactFloat(operator *token.Token, n int64) (interface{}, error) {
+	if n > numeric.MaxExactInteger || n < -numeric.MaxExactInteger {
+		return nil, errNotExact(operator)
+	}
+
+	return float64(n), nil
+}
+
+func errNotExact(operator *token.Token) error {
+	return le.NewRuntimeError(operator, "Integer result is too large for a float, use exact numbers.")
+}
+
+func bigIntegerBinary(operator *token.Token, lv, rv interface{}) (interface{}, error) {
+	l, err := toBigInteger(operator, lv)
+	if err != nil {
+		return nil, err
+	}
+	r, err := toBigInteger(operator, rv)
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(big.Int)
+	switch operator.Type {
+	case token.PERCENT:
+		if r.Sign() == 0 {
+			return nil, le.NewRuntimeError(operator, "Division by zero.")
+		}
+		// Rem和int64的 % 一样，结果的符号和被除数相同
+		return result.Rem(l, r), nil
+	case token.AMPERSAND:
+		return result.And(l, r), nil
+	case token.PIPE:
+		return result.Or(l, r), nil
+	case token.CARET:
+		return result.Xor(l, r), nil
+	case token.LESS_LESS, token.GREATER_GREATER:
+		if r.Sign() < 0 {
+			return nil, le.NewRuntimeError(operator, "Negative shift count.")
+		}
+		if operator.Type == token.GREATER_GREATER {
+			if !r.IsInt64() {
+				// 所有的位都被移出，非负数为0，负数为-1
+				return result.SetInt64(int64(l.Sign() >> 1)), nil
+			}
+			return result.Rsh(l, uint(r.Int64())), nil
+		}
+		if r.Cmp(big.NewInt(maxShift)) > 0 {
+			return nil, le.NewRuntimeError(operator, "Shift count too large.")
+		}
+		return result.Lsh(l, uint(r.Int64())), nil
+	}
+
+	return nil, nil
+}
+
+// invert 计算 ~v
+func invert(operator *token.Token, v interface{}) (interface{}, error) {
+	if _, ok := v.(float64); !ok {
+		n, err := toBigInteger(operator, v)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).Not(n), nil
+	}
+
+	n, err := toInteger(operator, v)
+	if err != nil {
+		return nil, err
+	}
+
+	return exactFloat(operator, ^n)
+}
@@ -1,41 +1,77 @@
 package interpreter
 
 import (
+	le "GLox/internal/loxerror"
 	parser2 "GLox/internal/parser"
 	"GLox/internal/scanner/token"
-	"time"
+	"context"
+	"io"
+	"os"
 )
 
 // Interpreter ExprVisitor 和 StmtVisitor 子类之一，计算表达式的值
 type Interpreter struct {
 	environment *Environment
-	globals     *Environment // globals 存放的是可以全局使用的native函数
+	globals     *Environment // globals 存放的是可以全局使用的native函数和顶层声明的变量
 	locals      map[parser2.Expr]int
+	stdout      io.Writer  // print语句的输出
+	tests       []TestCase // 通过 test() 注册的测试
+
+	limits    Limits
+	steps     int
+	depth     int
+	allocated int
+	deadline  context.Context // 设置了Timeout时，当前Interpret的截止时间
+	ctx       context.Context // 宿主程序通过InterpretContext传入，用于取消执行
+
+	profiler *Profiler
+	coverage *Coverage
+	inlined  map[*parser2.FuncDeclStmt]parser2.Expr // Optimize内联的getter -> 它返回的表达式
+
+	generator *LoxGenerator              // 正在执行的生成器，yield把值交给它的调用者
+	live      map[*LoxGenerator]struct{} // 已经开始、还没有结束的生成器
 }
 
 func NewInterpreter() *Interpreter {
 	g := NewEnvironment(nil)
-	g.defineLiteral("clock", NewLoxCallableImpl(func(interpreter *Interpreter, arguments []interface{}) interface{} {
-		return time.Now().Unix()
-	}, 0))
+	defineNatives(g)
 
 	return &Interpreter{
 		//environment: NewEnvironment(nil),
-		environment: NewEnvironment(g),
+		environment: g,
 		globals:     g,
 		locals:      make(map[parser2.Expr]int),
+		stdout:      os.Stdout,
+		ctx:         context.Background(),
 	}
 }
 
+// SetOutput 设置print语句的输出，默认为标准输出
+func (i *Interpreter) SetOutput(w io.Writer) {
+	i.stdout = w
+}
+
 // semantic.go
 
 // evaluate 计算表达式的值
 func (i *Interpreter) evaluate(expr parser2.Expr) (interface{}, error) {
+	if err := i.step(); err != nil {
+		return nil, err
+	}
+
 	return expr.Accept(i)
 }
 
 // execute 执行一个statement
 func (i *Interpreter) execute(stmt parser2.Stmt) error {
+	if err := i.step(); err != nil {
+		return err
+	}
+
+	if i.coverage != nil {
+		i.coverage.hit(stmt)
+	}
+
 	return stmt.Accept(i)
 }
 
@@ -60,6 +96,15 @@ func (i *Interpreter) executeBlock(block *parser2.BlockStmt, env *Environment) e
 	return nil
 }
 
+// checkCanceled 在循环的下一轮和函数调用之前检查执行是否已经被取消，at用于记录停止的位置
+func (i *Interpreter) checkCanceled(at *token.Token) error {
+	if err := i.ctx.Err(); err != nil {
+		return le.NewCancelError(at, err)
+	}
+
+	return nil
+}
+
 func (i *Interpreter) Resolve(expr parser2.Expr, depth int) {
 	i.locals[expr] = depth
 }
@@ -70,11 +115,28 @@ func (i *Interpreter) lookUpVariable(token *token.Token, expr parser2.Expr) (int
 		return i.environment.getAt(distance, token.Lexeme), nil
 	}
 
-	//return i.globals.lookup(token)
-	return i.environment.lookup(token)
+	// resolver没有找到的变量都是全局变量，不能沿着当前的作用域链查找，否则闭包会看到之后在block中声明的同名变量
+	return i.globals.lookup(token)
 }
 
 func (i *Interpreter) Interpret(stmts []parser2.Stmt) error {
+	return i.InterpretContext(context.Background(), stmts)
+}
+
+// InterpretContext 和Interpret一样执行语句，但是在每次循环和函数调用之前都会检查ctx，
+// ctx被取消之后返回一个包装了ctx.Err()的CancelError
+func (i *Interpreter) InterpretContext(ctx context.Context, stmts []parser2.Stmt) error {
+	previous := i.ctx
+	i.ctx = ctx
+	defer func() {
+		i.ctx = previous
+	}()
+	// 和Timeout一样，步数和内存的限制都是针对一次Interpret的
+	i.steps, i.allocated = 0, 0
+	defer i.withDeadline()()
+	// 生成器不能跨越Interpret使用，没有执行完的生成器在这里结束，它们的goroutine不会泄漏
+	defer i.closeGenerators()
+
 	for _, stmt := range stmts {
 		err := i.execute(stmt)
 		if err != nil {
@@ -0,0 +1,176 @@
+package interpreter
+
+import (
+	le "GLox/internal/loxerror"
+	"GLox/internal/numeric"
+	"GLox/internal/scanner/token"
+	"fmt"
+	"math/big"
+	"unicode/utf8"
+)
+
+// 迭代协议：实例通过定义iterator()返回一个迭代器，迭代器是定义了hasNext()和next()的实例
+const (
+	iteratorMethod = "iterator"
+	hasNextMethod  = "hasNext"
+	nextMethod     = "next"
+)
+
+// iterator 逐个产生for-in循环中的元素，ok为false表示已经没有元素了
+type iterator interface {
+	next(i *Interpreter) (value interface{}, ok bool, err error)
+}
+
+// closer 是需要在循环提前退出时释放资源的迭代器，目前只有生成器
+type closer interface {
+	close(i *Interpreter)
+}
+
+// iterate 返回遍历value的迭代器，at用于报告错误的位置
+func (i *Interpreter) iterate(at *token.Token, value interface{}) (iterator, error) {
+	switch value := value.(type) {
+	case *LoxList:
+		// 共享底层的数组，循环中通过下标修改的元素可以被遍历到
+		return &sliceIterator{elements: value.elements}, nil
+	case *LoxMap:
+		// 遍历开始循环时所有的key，循环中添加的key不会被遍历到
+		return &sliceIterator{elements: append([]interface{}(nil), value.keys...)}, nil
+	case string:
+		return &stringIterator{s: value}, nil
+	case *LoxRange:
+		return &rangeIterator{r: value, current: value.start}, nil
+	case *LoxGenerator:
+		return &generatorIterator{at: at, generator: value}, nil
+	case *LoxInstance:
+		if value.class.findMethod(iteratorMethod) == nil {
+			return i.protocolIterator(at, value)
+		}
+
+		result, _, err := i.invokeSpecial(value, iteratorMethod, at)
+		if err != nil {
+			return nil, err
+		}
+		// iterator()也可以返回list等内置的可迭代的值
+		if instance, ok := result.(*LoxInstance); ok {
+			return i.protocolIterator(at, instance)
+		}
+		return i.iterate(at, result)
+	}
+
+	return nil, le.NewRuntimeError(at, "Can only iterate over lists, maps, strings, ranges, generators and instances defining 'iterator()'.")
+}
+
+// protocolIterator 检查实例是否定义了hasNext()和next()
+func (i *Interpreter) protocolIterator(at *token.Token, instance *LoxInstance) (iterator, error) {
+	if instance.class.findMethod(hasNextMethod) == nil || instance.class.findMethod(nextMethod) == nil {
+		return nil, le.NewRuntimeError(at, "Iterator '"+instance.String()+"' must define 'hasNext()' and 'next()'.")
+	}
+
+	return &instanceIterator{at: at, instance: instance}, nil
+}
+
+type sliceIterator struct {
+	elements []interface{}
+	index    int
+}
+
+func (it *sliceIterator) next(_ *Interpreter) (interface{}, bool, error) {
+	if it.index >= len(it.elements) {
+		return nil, false, nil
+	}
+
+	it.index++
+	return it.elements[it.index-1], true, nil
+}
+
+// stringIterator 按照字符（而不是字节）遍历字符串
+type stringIterator struct {
+	s string
+}
+
+func (it *stringIterator) next(_ *Interpreter) (interface{}, bool, error) {
+	if it.s == "" {
+		return nil, false, nil
+	}
+
+	_, size := utf8.DecodeRuneInString(it.s)
+	char := it.s[:size]
+	it.s = it.s[size:]
+
+	return char, true, nil
+}
+
+type instanceIterator struct {
+	at       *token.Token
+	instance *LoxInstance
+}
+
+func (it *instanceIterator) next(i *Interpreter) (interface{}, bool, error) {
+	hasNext, _, err := i.invokeSpecial(it.instance, hasNextMethod, it.at)
+	if err != nil || !isTruth(hasNext) {
+		return nil, false, err
+	}
+
+	value, _, err := i.invokeSpecial(it.instance, nextMethod, it.at)
+
+	return value, err == nil, err
+}
+
+// LoxRange 是range()的返回值，表示从start开始、以step为步长、不包含stop的等差数列
+type LoxRange struct {
+	start, stop, step interface{}
+	descending        bool
+}
+
+func (lr *LoxRange) String() string {
+	return fmt.Sprintf("range(%v, %v, %v)", lr.start, lr.stop, lr.step)
+}
+
+type rangeIterator struct {
+	r       *LoxRange
+	current interface{}
+}
+
+func (it *rangeIterator) next(_ *Interpreter) (interface{}, bool, error) {
+	c, ok := numeric.Compare(it.current, it.r.stop)
+	if !ok || (!it.r.descending && c >= 0) || (it.r.descending && c <= 0) {
+		return nil, false, nil
+	}
+
+	value := it.current
+	it.current, _ = numeric.Arith(token.PLUS, it.current, it.r.step)
+
+	return value, true, nil
+}
+
+// rangeOf 实现 range(stop)、range(start, stop) 和 range(start, stop, step)，省略的start为0，step为1。
+// 所有的参数都是精确的数字时，省略的参数也是精确的整数
+func rangeOf(_ *Interpreter, _ *token.Token, arguments []interface{}) (interface{}, error) {
+	var zero, one interface{} = new(big.Int), big.NewInt(1)
+	for _, argument := range arguments {
+		if !numeric.IsNumber(argument) {
+			return nil, nativeError("range() expects numbers.")
+		}
+		if _, ok := argument.(float64); ok {
+			zero, one = float64(0), float64(1)
+		}
+	}
+
+	r := &LoxRange{start: zero, step: one}
+	switch len(arguments) {
+	case 1:
+		r.stop = arguments[0]
+	case 2:
+		r.start, r.stop = arguments[0], arguments[1]
+	default:
+		r.start, r.stop, r.step = arguments[0], arguments[1], arguments[2]
+	}
+
+	c, ok := numeric.Compare(r.step, zero)
+	if !ok || c == 0 {
+		return nil, nativeError("range() step must not be zero.")
+	}
+	r.descending = c < 0
+
+	return r, nil
+}
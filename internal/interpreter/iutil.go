@@ -2,7 +2,9 @@ package interpreter
 
 import (
 	"GLox/internal/loxerror"
+	"GLox/internal/numeric"
 	"GLox/internal/scanner/token"
+	"math/big"
 )
 
 // isTruth Lox中规定nil和false为"假"，其余都为真
@@ -20,14 +22,12 @@ func isTruth(any interface{}) bool {
 }
 
 func doPlus(operator *token.Token, left, right interface{}) (interface{}, error) {
-	_, ok1 := left.(float64)
-	_, ok2 := right.(float64)
-	if ok1 && ok2 {
-		return left.(float64) + right.(float64), nil
+	if numeric.IsNumber(left) && numeric.IsNumber(right) {
+		return arith(operator, left, right)
 	}
 
-	_, ok1 = left.(string)
-	_, ok2 = right.(string)
+	_, ok1 := left.(string)
+	_, ok2 := right.(string)
 	if ok1 && ok2 {
 		return left.(string) + right.(string), nil
 	}
@@ -45,13 +45,46 @@ func isEqual(left, right interface{}) bool {
 	if (left == nil && right != nil) || (left != nil && right == nil) {
 		return false
 	}
+	// 不同类型的数字按照数值比较，*big.Int也不能直接用 == 比较
+	if numeric.IsNumber(left) && numeric.IsNumber(right) {
+		return numeric.Equal(left, right)
+	}
 
 	return left == right
 }
 
+// arith 计算两个数字的 + - * /，不同类型的数字按照numeric包中的规则提升
+func arith(operator *token.Token, left, right interface{}) (interface{}, error) {
+	result, err := numeric.Arith(operator.Type, left, right)
+	if err != nil {
+		return nil, loxerror.NewRuntimeError(operator, err.Error())
+	}
+
+	return result, nil
+}
+
+// compare 计算两个数字的 > >= < <=，和NaN比较的结果总是false
+func compare(operator *token.Token, left, right interface{}) bool {
+	c, ok := numeric.Compare(left, right)
+	if !ok {
+		return false
+	}
+
+	switch operator.Type {
+	case token.GREATER:
+		return c > 0
+	case token.GREATER_EQUAL:
+		return c >= 0
+	case token.LESS:
+		return c < 0
+	}
+
+	return c <= 0
+}
+
 func checkNumberOperands(operator *token.Token, operands ...interface{}) error {
 	for _, operand := range operands {
-		if _, ok := operand.(float64); !ok {
+		if !numeric.IsNumber(operand) {
 			//panic(loxerror.NewRuntimeError(operator, "Operand must be a number."))
 			return loxerror.NewRuntimeError(operator, "Operand must be a number.")
 		}
@@ -59,3 +92,17 @@ func checkNumberOperands(operator *token.Token, operands ...interface{}) error {
 
 	return nil
 }
+
+// checkIndex 检查下标是否为[0, length)范围内的整数
+func checkIndex(bracket *token.Token, index interface{}, length int) (int, error) {
+	n, ok := numeric.Int(index)
+	if !ok {
+		return 0, loxerror.NewRuntimeError(bracket, "Index must be an integer.")
+	}
+
+	if n.Sign() < 0 || n.Cmp(big.NewInt(int64(length))) >= 0 {
+		return 0, loxerror.NewRuntimeError(bracket, "Index out of range.")
+	}
+
+	return int(n.Int64()), nil
+}
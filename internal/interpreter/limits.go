@@ -0,0 +1,83 @@
+package interpreter
+
+import (
+	le "GLox/internal/loxerror"
+	"context"
+	"fmt"
+	"time"
+)
+
+// Limits 限制脚本可以使用的资源，字段为零值表示不做限制。每次Interpret都重新计算步数、时间和内存
+type Limits struct {
+	MaxSteps     int           // execute和evaluate的最大调用次数
+	Timeout      time.Duration // 一次Interpret的最长执行时间
+	MaxCallDepth int           // Lox函数调用的最大嵌套深度
+	MaxMemory    int           // list、实例和字符串近似占用的最大字节数
+}
+
+// deadlineInterval 每执行这么多步检查一次是否超时，避免每一步都去读context
+const deadlineInterval = 1024
+
+// 估算分配的大小时使用的常量，只需要大致反映值的占用
+const (
+	listSize     = 24
+	elementSize  = 16
+	entrySize    = 2 * elementSize
+	instanceSize = 48
+	fieldSize    = 16
+)
+
+// SetLimits 设置之后执行的脚本需要遵守的限制
+func (i *Interpreter) SetLimits(limits Limits) {
+	i.limits = limits
+}
+
+// step 在每次execute和evaluate的时候调用
+func (i *Interpreter) step() error {
+	i.steps++
+	if i.limits.MaxSteps > 0 && i.steps > i.limits.MaxSteps {
+		return le.NewLimitError("steps", fmt.Sprintf("exceeded the maximum of %d steps.", i.limits.MaxSteps))
+	}
+
+	if i.deadline != nil && i.steps%deadlineInterval == 0 && i.deadline.Err() != nil {
+		return le.NewLimitError("timeout", fmt.Sprintf("exceeded the timeout of %v.", i.limits.Timeout))
+	}
+
+	return nil
+}
+
+// enterCall 在调用Lox函数之前检查调用深度，返回的函数用于在调用结束时恢复深度
+func (i *Interpreter) enterCall() (func(), error) {
+	if i.limits.MaxCallDepth > 0 && i.depth >= i.limits.MaxCallDepth {
+		return nil, le.NewLimitError("call depth", fmt.Sprintf("exceeded the maximum call depth of %d.", i.limits.MaxCallDepth))
+	}
+
+	i.depth++
+
+	return func() { i.depth-- }, nil
+}
+
+// allocate 记录新分配的值占用的字节数
+func (i *Interpreter) allocate(size int) error {
+	i.allocated += size
+	if i.limits.MaxMemory > 0 && i.allocated > i.limits.MaxMemory {
+		return le.NewLimitError("memory", fmt.Sprintf("exceeded the memory limit of %d bytes.", i.limits.MaxMemory))
+	}
+
+	return nil
+}
+
+// withDeadline 为一次Interpret设置超时，返回的函数用于释放context
+func (i *Interpreter) withDeadline() func() {
+	if i.limits.Timeout <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), i.limits.Timeout)
+	i.deadline = ctx
+
+	return func() {
+		cancel()
+		i.deadline = nil
+	}
+}
@@ -0,0 +1,91 @@
+package interpreter_test
+
+import (
+	"GLox/internal/interpreter"
+	le "GLox/internal/loxerror"
+	"GLox/internal/parser"
+	"GLox/internal/resolver"
+	"GLox/internal/scanner"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+// prepare 解析并resolve脚本，返回可以直接执行的interpreter
+func prepare(t *testing.T, source string) (*interpreter.Interpreter, []parser.Stmt) {
+	t.Helper()
+
+	stmts, errs := parser.NewParser(scanner.NewScanner(source).ScanTokens()).Parse()
+	if le.HadError || len(errs) > 0 {
+		t.Fatalf("parse error in %q: %v", source, errs)
+	}
+
+	i := interpreter.NewInterpreter()
+	i.SetOutput(ioutil.Discard)
+	resolver.NewResolver(i).ResolveStmt(stmts...)
+
+	return i, stmts
+}
+
+func interpretWithLimits(t *testing.T, source string, limits interpreter.Limits) error {
+	i, stmts := prepare(t, source)
+	i.SetLimits(limits)
+
+	return i.Interpret(stmts)
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		limits interpreter.Limits
+		limit  string
+	}{
+		{"steps", "while (true) {}", interpreter.Limits{MaxSteps: 1000}, "steps"},
+		{"timeout", "while (true) {}", interpreter.Limits{Timeout: 10 * time.Millisecond}, "timeout"},
+		{"call depth", "fun f() { f(); } f();", interpreter.Limits{MaxCallDepth: 50}, "call depth"},
+		{"list memory", "var l = nil; while (true) { l = [l, l, l]; }", interpreter.Limits{MaxMemory: 1 << 16}, "memory"},
+		{"string memory", `var s = "a"; while (true) { s = s + s; }`, interpreter.Limits{MaxMemory: 1 << 20}, "memory"},
+		{"instance memory", "class A {} var a = nil; while (true) { a = A(); a.next = a; }", interpreter.Limits{MaxMemory: 1 << 16}, "memory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := interpretWithLimits(t, tt.source, tt.limits)
+
+			var limitErr *le.LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("expected a LimitError, but got %v", err)
+			}
+			if limitErr.Limit != tt.limit {
+				t.Errorf("expected limit %q, but got %q", tt.limit, limitErr.Limit)
+			}
+		})
+	}
+}
+
+func TestLimitsNotExceeded(t *testing.T) {
+	source := `
+fun fib(n) { if (n < 2) return n; return fib(n - 1) + fib(n - 2); }
+var r = fib(10);
+var s = "lox" + "lox";
+var l = [1, 2, 3];`
+
+	limits := interpreter.Limits{MaxSteps: 100000, Timeout: time.Second, MaxCallDepth: 20, MaxMemory: 1024}
+	if err := interpretWithLimits(t, source, limits); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 同一个interpreter多次Interpret时，每一次都有完整的步数和内存限制
+func TestLimitsPerInterpret(t *testing.T) {
+	i, stmts := prepare(t, `var l = nil; for (var n = 0; n < 10; n++) l = [l, n];`)
+	i.SetLimits(interpreter.Limits{MaxSteps: 200, MaxMemory: 800})
+
+	for n := 0; n < 5; n++ {
+		if err := i.Interpret(stmts); err != nil {
+			t.Fatalf("run %d: %v", n+1, err)
+		}
+	}
+}
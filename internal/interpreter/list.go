@@ -0,0 +1,24 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LoxList is the runtime value of a list literal, e.g. [1, "two", nil].
+type LoxList struct {
+	elements []interface{}
+}
+
+func NewLoxList(elements []interface{}) *LoxList {
+	return &LoxList{elements: elements}
+}
+
+func (ll *LoxList) String() string {
+	var items []string
+	for _, element := range ll.elements {
+		items = append(items, fmt.Sprintf("%v", element))
+	}
+
+	return "[" + strings.Join(items, ", ") + "]"
+}
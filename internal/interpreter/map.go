@@ -0,0 +1,76 @@
+package interpreter
+
+import (
+	le "GLox/internal/loxerror"
+	"GLox/internal/numeric"
+	"GLox/internal/scanner/token"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// LoxMap 是map字面量（如 {"a": 1, 2: nil}）的值，按照key插入的顺序遍历
+type LoxMap struct {
+	keys   []interface{}
+	values []interface{}
+	index  map[interface{}]int // hashKey(key) -> 在keys和values中的位置
+}
+
+func NewLoxMap() *LoxMap {
+	return &LoxMap{index: make(map[interface{}]int)}
+}
+
+// get 返回key对应的值，key不存在时返回nil
+func (lm *LoxMap) get(hashed interface{}) interface{} {
+	if i, ok := lm.index[hashed]; ok {
+		return lm.values[i]
+	}
+
+	return nil
+}
+
+// set 添加或者修改一个entry，添加了新的entry时返回true
+func (lm *LoxMap) set(hashed, key, value interface{}) bool {
+	if i, ok := lm.index[hashed]; ok {
+		lm.values[i] = value
+		return false
+	}
+
+	lm.index[hashed] = len(lm.keys)
+	lm.keys = append(lm.keys, key)
+	lm.values = append(lm.values, value)
+
+	return true
+}
+
+func (lm *LoxMap) String() string {
+	var items []string
+	for i, key := range lm.keys {
+		items = append(items, fmt.Sprintf("%v: %v", key, lm.values[i]))
+	}
+
+	return "{" + strings.Join(items, ", ") + "}"
+}
+
+// numberKey 是数字在map中的key，和 == 一样按照精确的数值区分，所以 1、1.0 和 1.00d 对应同一个key，
+// 而0.1和0.1d不是同一个key
+type numberKey string
+
+// hashKey 把map的key转换成可以作为Go map的key的值。
+// *big.Int和Decimal内部有指针，不能直接比较，所以数字都按照数值转换成numberKey，其他的值按照 == 比较
+func hashKey(bracket *token.Token, key interface{}) (interface{}, error) {
+	if !numeric.IsNumber(key) {
+		return key, nil
+	}
+
+	if f, ok := key.(float64); ok {
+		if math.IsNaN(f) {
+			return nil, le.NewRuntimeError(bracket, "NaN can't be used as a map key.")
+		}
+		if math.IsInf(f, 0) {
+			return numberKey(fmt.Sprint(f)), nil
+		}
+	}
+
+	return numberKey(numeric.Rat(key).RatString()), nil
+}
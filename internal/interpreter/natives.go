@@ -0,0 +1,249 @@
+package interpreter
+
+import (
+	"GLox/internal/numeric"
+	"GLox/internal/scanner/token"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// nativeError 是native函数返回的错误，调用处会把它包装成带有行号的RuntimeError
+type nativeError string
+
+func (e nativeError) Error() string {
+	return string(e)
+}
+
+// defineNatives 在全局作用域中定义所有的native函数，包括assert.go中用于测试的函数
+func defineNatives(globals *Environment) {
+	natives := map[string]*Native{
+		"clock":      NewLoxCallableImpl(clock, 0),
+		"len":        NewLoxCallableImpl(length, 1),
+		"type":       NewLoxCallableImpl(typeOf, 1),
+		"instanceof": NewLoxCallableImpl(instanceOf, 2),
+		"fields":     NewLoxCallableImpl(fields, 1),
+		"methods":    NewLoxCallableImpl(methods, 1),
+		"hasattr":    NewLoxCallableImpl(hasAttr, 2),
+		"getattr":    NewLoxCallableImpl(getAttr, 2),
+		"setattr":    NewLoxCallableImpl(setAttr, 3),
+		"delattr":    NewLoxCallableImpl(delAttr, 2),
+		"arity":      NewLoxCallableImpl(arity, 1),
+		"range":      NewLoxCallableImpl(rangeOf, 3).withOptional(2),
+	}
+
+	defineAssertions(natives)
+
+	for name, native := range natives {
+		native.name = name
+		globals.defineLiteral(name, native)
+	}
+}
+
+// clock 返回当前的Unix时间，单位为秒。Lox的数字是float64，所以整数秒也要转换
+func clock(_ *Interpreter, _ *token.Token, _ []interface{}) (interface{}, error) {
+	return float64(time.Now().Unix()), nil
+}
+
+// length 返回字符串、list或者map的长度
+func length(_ *Interpreter, _ *token.Token, arguments []interface{}) (interface{}, error) {
+	switch value := arguments[0].(type) {
+	case string:
+		return float64(len(value)), nil
+	case *LoxList:
+		return float64(len(value.elements)), nil
+	case *LoxMap:
+		return float64(len(value.keys)), nil
+	}
+
+	return nil, nativeError("len() expects a string, a list or a map.")
+}
+
+// typeOf 返回值的类型名称
+func typeOf(_ *Interpreter, _ *token.Token, arguments []interface{}) (interface{}, error) {
+	switch arguments[0].(type) {
+	case nil:
+		return "nil", nil
+	case bool:
+		return "bool", nil
+	case float64, *big.Int, numeric.Decimal:
+		return "number", nil
+	case string:
+		return "string", nil
+	case *LoxClass:
+		return "class", nil
+	case *LoxInstance:
+		return "instance", nil
+	case *LoxList:
+		return "list", nil
+	case *LoxMap:
+		return "map", nil
+	case *LoxRange:
+		return "range", nil
+	case *LoxGenerator:
+		return "generator", nil
+	case *LoxTrait:
+		return "trait", nil
+	case LoxCallable:
+		return "function", nil
+	}
+
+	return nil, nativeError("type() got a value of unknown type.")
+}
+
+// instanceOf 判断对象是否是某个类（包括它的子类）的实例，或者它的类是否混入了某个trait
+func instanceOf(_ *Interpreter, _ *token.Token, arguments []interface{}) (interface{}, error) {
+	instance, ok := arguments[0].(*LoxInstance)
+	if !ok {
+		return false, nil
+	}
+
+	switch target := arguments[1].(type) {
+	case *LoxClass:
+		for class := instance.class; class != nil; class = class.superclass {
+			if class == target {
+				return true, nil
+			}
+		}
+	case *LoxTrait:
+		for class := instance.class; class != nil; class = class.superclass {
+			for _, trait := range class.traits {
+				if trait == target {
+					return true, nil
+				}
+			}
+		}
+	default:
+		return nil, nativeError("instanceof() expects a class or a trait as the second argument.")
+	}
+
+	return false, nil
+}
+
+// fields 返回实例中所有字段的名称，按字典序排列
+func fields(_ *Interpreter, _ *token.Token, arguments []interface{}) (interface{}, error) {
+	instance, ok := arguments[0].(*LoxInstance)
+	if !ok {
+		return nil, nativeError("fields() expects an instance.")
+	}
+
+	var names []string
+	for name := range instance.fields {
+		names = append(names, name)
+	}
+
+	return sortedList(names), nil
+}
+
+// methods 返回类中（包括继承的）所有方法的名称，按字典序排列
+func methods(_ *Interpreter, _ *token.Token, arguments []interface{}) (interface{}, error) {
+	class, ok := arguments[0].(*LoxClass)
+	if !ok {
+		return nil, nativeError("methods() expects a class.")
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for ; class != nil; class = class.superclass {
+		for name := range class.methods {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	return sortedList(names), nil
+}
+
+func hasAttr(_ *Interpreter, _ *token.Token, arguments []interface{}) (interface{}, error) {
+	instance, name, err := attributeArguments("hasattr", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	return instance.Has(name), nil
+}
+
+func getAttr(interpreter *Interpreter, site *token.Token, arguments []interface{}) (interface{}, error) {
+	instance, name, err := attributeArguments("getattr", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	if !instance.Has(name) {
+		return nil, nativeError("undefined attribute '" + name + "'.")
+	}
+
+	return instance.Get(interpreter, attributeToken(name, site))
+}
+
+func setAttr(interpreter *Interpreter, site *token.Token, arguments []interface{}) (interface{}, error) {
+	instance, name, err := attributeArguments("setattr", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	return arguments[2], instance.Set(interpreter, attributeToken(name, site), arguments[2])
+}
+
+// delAttr 只能删除实例中的字段，方法和getter属于类，不能被删除
+func delAttr(_ *Interpreter, _ *token.Token, arguments []interface{}) (interface{}, error) {
+	instance, name, err := attributeArguments("delattr", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := instance.fields[name]; !ok {
+		return nil, nativeError("undefined field '" + name + "'.")
+	}
+	delete(instance.fields, name)
+
+	return nil, nil
+}
+
+func arity(_ *Interpreter, _ *token.Token, arguments []interface{}) (interface{}, error) {
+	callable, ok := arguments[0].(LoxCallable)
+	if !ok {
+		return nil, nativeError("arity() expects a function or a class.")
+	}
+
+	return float64(callable.Arity()), nil
+}
+
+// attributeArguments 检查 *attr 系列函数的前两个参数：一个实例和一个属性名
+func attributeArguments(fn string, arguments []interface{}) (*LoxInstance, string, error) {
+	instance, ok := arguments[0].(*LoxInstance)
+	if !ok {
+		return nil, "", nativeError(fn + "() expects an instance as the first argument.")
+	}
+
+	name, ok := arguments[1].(string)
+	if !ok {
+		return nil, "", nativeError(fn + "() expects a string as the attribute name.")
+	}
+
+	return instance, name, nil
+}
+
+// attributeToken 为通过字符串访问的属性构造一个Token，方便复用LoxInstance的Get和Set。
+// 它的行号取自getattr或setattr的调用位置，getter和setter的调用也记在这一行
+func attributeToken(name string, site *token.Token) *token.Token {
+	line := 0
+	if site != nil {
+		line = site.Line
+	}
+
+	return token.NewToken(token.IDENTIFIER, name, nil, line)
+}
+
+func sortedList(names []string) *LoxList {
+	sort.Strings(names)
+
+	elements := make([]interface{}, len(names))
+	for i, name := range names {
+		elements[i] = name
+	}
+
+	return NewLoxList(elements)
+}
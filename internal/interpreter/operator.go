@@ -0,0 +1,80 @@
+package interpreter
+
+import (
+	le "GLox/internal/loxerror"
+	"GLox/internal/scanner/token"
+	"fmt"
+)
+
+// operatorMethods 类可以通过定义这些特殊方法来重载对应的运算符
+var operatorMethods = map[token.TokenType]string{
+	token.PLUS:            "__add__",
+	token.MINUS:           "__sub__",
+	token.STAR:            "__mul__",
+	token.SLASH:           "__div__",
+	token.PERCENT:         "__mod__",
+	token.AMPERSAND:       "__and__",
+	token.PIPE:            "__or__",
+	token.CARET:           "__xor__",
+	token.LESS_LESS:       "__lshift__",
+	token.GREATER_GREATER: "__rshift__",
+	token.LESS:            "__lt__",
+	token.LESS_EQUAL:      "__le__",
+	token.GREATER:         "__gt__",
+	token.GREATER_EQUAL:   "__ge__",
+}
+
+const (
+	eqMethod       = "__eq__"
+	negMethod      = "__neg__"
+	invertMethod   = "__invert__"
+	indexMethod    = "__index__"
+	setIndexMethod = "__setindex__"
+)
+
+// invokeSpecial calls the special method `name` on instance if its class (or a superclass) defines it,
+// found reports whether such a method exists.
+func (i *Interpreter) invokeSpecial(instance *LoxInstance, name string, at *token.Token, arguments ...interface{}) (result interface{}, found bool, err error) {
+	method := instance.class.findMethod(name)
+	if method == nil {
+		return nil, false, nil
+	}
+
+	if method.Arity() != len(arguments) {
+		return nil, true, le.NewRuntimeError(at, fmt.Sprintf("Method '%s' expects %d arguments but got %d.", name, method.Arity(), len(arguments)))
+	}
+
+	result, err = method.bind(instance).Call(i, at, arguments)
+
+	return result, true, err
+}
+
+// binaryOverload dispatches an arithmetic or comparison operator to the special method of the left operand.
+func (i *Interpreter) binaryOverload(operator *token.Token, left, right interface{}) (interface{}, bool, error) {
+	instance, ok := left.(*LoxInstance)
+	if !ok {
+		return nil, false, nil
+	}
+
+	name, ok := operatorMethods[operator.Type]
+	if !ok {
+		return nil, false, nil
+	}
+
+	return i.invokeSpecial(instance, name, operator, right)
+}
+
+// equals 判断两个值是否相等，实例可以通过定义 __eq__ 来自定义相等的语义，
+// 只要任意一侧的操作数定义了 __eq__ 就会调用它，"!=" 的结果是 "==" 取反
+func (i *Interpreter) equals(operator *token.Token, left, right interface{}) (bool, error) {
+	for _, operands := range [][2]interface{}{{left, right}, {right, left}} {
+		if instance, ok := operands[0].(*LoxInstance); ok {
+			result, found, err := i.invokeSpecial(instance, eqMethod, operator, operands[1])
+			if found {
+				return isTruth(result), err
+			}
+		}
+	}
+
+	return isEqual(left, right), nil
+}
@@ -0,0 +1,186 @@
+package interpreter
+
+import (
+	"GLox/internal/numeric"
+	parser2 "GLox/internal/parser"
+	"GLox/internal/scanner/token"
+	"GLox/utils"
+	"math/big"
+)
+
+// Optimize 是resolver和Interpret之间可选的优化，原地改写语法树并返回新的program：
+//   - 折叠只包含字面量的 Binary、Unary、Logic 和 Grouping 表达式，以及条件为常量的条件表达式，计算出错的表达式保持不变，留到运行时报错
+//   - 删除条件为常量的if/while中不会执行的分支，以及block中return之后的statement
+//   - 记录函数体只有 return 字面量 或者 return this.xxx 的getter，访问时直接计算，不再调用函数
+//
+// 优化不会改变程序的输出，但是会减少执行的步数，profile和coverage中也不会再出现被删除或者内联的代码
+func (i *Interpreter) Optimize(program []parser2.Stmt) []parser2.Stmt {
+	o := &optimizer{interpreter: i, folder: NewInterpreter()}
+
+	return pruneStmts(parser2.RewriteProgram(program, o.rewrite))
+}
+
+type optimizer struct {
+	interpreter *Interpreter
+	folder      *Interpreter // 用来计算常量表达式，保证和运行时的语义完全一致
+}
+
+// rewrite 自底向上地调用，处理到一个节点的时候它的子节点已经优化过了
+func (o *optimizer) rewrite(node parser2.Node) parser2.Node {
+	switch n := node.(type) {
+	case *parser2.Grouping:
+		if isLiteral(n.Expression) {
+			return n.Expression
+		}
+	case *parser2.Unary:
+		if isLiteral(n.Right) {
+			return o.fold(n)
+		}
+	case *parser2.Binary:
+		if isLiteral(n.Left) && isLiteral(n.Right) {
+			return o.fold(n)
+		}
+	case *parser2.Logic:
+		// and/or/?? 的结果是其中一侧的值，左侧是常量的时候就可以确定是哪一侧
+		if left, ok := n.Left.(*parser2.Literal); ok {
+			keepLeft := isTruth(left.Value) == (n.Operator.Type == token.OR)
+			if n.Operator.Type == token.QUESTION_QUESTION {
+				keepLeft = left.Value != nil
+			}
+			if keepLeft {
+				return left
+			}
+			return n.Right
+		}
+	case *parser2.Conditional:
+		if condition, ok := n.Condition.(*parser2.Literal); ok {
+			return utils.Ternary(isTruth(condition.Value), n.ThenBranch, n.ElseBranch)
+		}
+	case *parser2.IfStmt:
+		if condition, ok := n.Condition.(*parser2.Literal); ok {
+			if isTruth(condition.Value) {
+				return n.ThenBranch
+			}
+			if n.ElseBranch != nil {
+				return n.ElseBranch
+			}
+			// 用空的block代替，if语句可能是另一个语句的分支或者循环体，不能直接删除
+			return parser2.NewBlockStmt(nil)
+		}
+	case *parser2.WhileStmt:
+		if condition, ok := n.Condition.(*parser2.Literal); ok && !isTruth(condition.Value) {
+			return parser2.NewBlockStmt(nil)
+		}
+	case *parser2.BlockStmt:
+		n.Stmts = pruneStmts(n.Stmts)
+	case *parser2.ClassDeclStmt:
+		o.inlineGetters(n)
+	}
+
+	return node
+}
+
+// fold 计算常量表达式的值，生成的字面量使用表达式开始位置的token
+func (o *optimizer) fold(expr parser2.Expr) parser2.Expr {
+	value, err := o.folder.evaluate(expr)
+	if err != nil {
+		return expr
+	}
+
+	pos := expr.Pos()
+	if !pos.IsValid() {
+		return parser2.NewLiteral(value)
+	}
+
+	t := token.NewToken(literalType(value), literalLexeme(value), value, pos.Line)
+	t.Column = pos.Column
+
+	return parser2.NewTokenLiteral(value, t)
+}
+
+// inlineGetters 记录可以内联的getter，见 Interpreter.inlineGetter
+func (o *optimizer) inlineGetters(class *parser2.ClassDeclStmt) {
+	for _, getter := range class.Getters {
+		if len(getter.Body.Stmts) != 1 {
+			continue
+		}
+		ret, ok := getter.Body.Stmts[0].(*parser2.ReturnStmt)
+		if !ok {
+			continue
+		}
+
+		switch value := ret.Value.(type) {
+		case *parser2.Literal:
+		case *parser2.Get:
+			if _, ok := value.Object.(*parser2.This); !ok {
+				continue
+			}
+		default:
+			continue
+		}
+
+		if o.interpreter.inlined == nil {
+			o.interpreter.inlined = make(map[*parser2.FuncDeclStmt]parser2.Expr)
+		}
+		o.interpreter.inlined[getter] = ret.Value
+	}
+}
+
+// inlineGetter 直接计算被内联的getter的值，ok为false表示getter没有被内联，需要正常调用
+func (i *Interpreter) inlineGetter(instance *LoxInstance, getter *LoxFunction) (value interface{}, ok bool, err error) {
+	switch expr := i.inlined[getter.declaration].(type) {
+	case *parser2.Literal:
+		return expr.Value, true, nil
+	case *parser2.Get:
+		value, err = instance.Get(i, expr.Attribute)
+		return value, true, err
+	}
+
+	return nil, false, nil
+}
+
+// pruneStmts 删除空的block和return之后的statement
+func pruneStmts(stmts []parser2.Stmt) []parser2.Stmt {
+	pruned := stmts[:0]
+	for _, stmt := range stmts {
+		if block, ok := stmt.(*parser2.BlockStmt); ok && len(block.Stmts) == 0 {
+			continue
+		}
+
+		pruned = append(pruned, stmt)
+		if _, ok := stmt.(*parser2.ReturnStmt); ok {
+			break
+		}
+	}
+
+	return pruned
+}
+
+func isLiteral(expr parser2.Expr) bool {
+	_, ok := expr.(*parser2.Literal)
+	return ok
+}
+
+func literalType(value interface{}) token.TokenType {
+	switch value := value.(type) {
+	case float64, *big.Int, numeric.Decimal:
+		return token.NUMBER
+	case string:
+		return token.STRING
+	case bool:
+		return utils.Ternary(value, token.TRUE, token.FALSE)
+	}
+
+	return token.NIL
+}
+
+func literalLexeme(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return `"` + value + `"`
+	case nil:
+		return "nil"
+	}
+
+	return utils.ToString(value)
+}
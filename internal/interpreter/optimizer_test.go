@@ -0,0 +1,119 @@
+package interpreter_test
+
+import (
+	"GLox/internal/interpreter"
+	"GLox/internal/parser"
+	"bytes"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+const optimizerSource = `print 1 + 2 * 3;
+print -(4 - 6) == 2 and !nil;
+print "a" + "b" + 1 == 1;
+print nil or "default";
+print false and undefined;
+
+fun f(x) {
+  if (false) print "unreachable";
+  if (1 < 2) { print "then"; } else { print "else"; }
+  while (nil) print "never";
+  return x * 2;
+  print "after return";
+}
+print f(21);
+
+class Circle {
+  init(r) { this.r = r; }
+  pi { return 3 + 0.14; }
+  radius { return this.r; }
+  area { return this.pi * this.r * this.r; }
+}
+var c = Circle(2);
+print c.pi;
+print c.radius;
+print c.area;
+c.r = 1;
+print c.radius;
+
+print "x" - 1;`
+
+func interpretOptimized(t *testing.T, source string, optimize bool) (string, error) {
+	t.Helper()
+
+	var output bytes.Buffer
+	i, stmts := prepare(t, source)
+	i.SetOutput(&output)
+	if optimize {
+		stmts = i.Optimize(stmts)
+	}
+	err := i.Interpret(stmts)
+
+	return output.String(), err
+}
+
+func TestOptimizeOutput(t *testing.T) {
+	output, err := interpretOptimized(t, optimizerSource, false)
+	optimized, optimizedErr := interpretOptimized(t, optimizerSource, true)
+
+	if output != optimized {
+		t.Errorf("output without optimization:\n%s\nwith optimization:\n%s", output, optimized)
+	}
+	if err == nil || optimizedErr == nil || err.Error() != optimizedErr.Error() {
+		t.Errorf("expected the same runtime error, got %v and %v", err, optimizedErr)
+	}
+}
+
+func TestOptimizeTree(t *testing.T) {
+	i, stmts := prepare(t, `print 1 + 2 * 3;
+print (1 < 2) or x;
+print false or x;
+if (false) print 1;
+if ("yes") print 2; else print 3;
+while (false) print 4;
+fun f() { return 1; print 5; }
+print nil ?? x;
+print 0 ?? x;
+print 1 > 2 ? x : "no";`)
+	stmts = i.Optimize(stmts)
+
+	want := `(print 7)
+(print true)
+(print x)
+(print 2)
+(fun f () (return 1))
+(print x)
+(print 0)
+(print no)`
+	if got := (&parser.Printer{}).PrintProgram(stmts); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestOptimizeInlineGetters(t *testing.T) {
+	i, stmts := prepare(t, `class A {
+  init() { this.v = 1; }
+  constant { return 2; }
+  value { return this.v; }
+  sum { return this.v + 2; }
+}
+var a = A();
+print a.constant + a.value + a.sum;`)
+	profiler := interpreter.NewProfiler()
+	i.SetProfiler(profiler)
+	if err := i.Interpret(i.Optimize(stmts)); err != nil {
+		t.Fatal(err)
+	}
+
+	var called []string
+	for _, entry := range profiler.Functions() {
+		called = append(called, entry.Name)
+	}
+	// 只有init和不是trivial的sum会被调用
+	want := []string{"A", "init:2", "sum:5"}
+	sort.Strings(called)
+	if !reflect.DeepEqual(called, want) {
+		t.Errorf("called %v, want %v", called, want)
+	}
+}
@@ -0,0 +1,206 @@
+package interpreter
+
+import (
+	"GLox/internal/scanner/token"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Profiler 记录每个函数和每个调用位置的调用次数和耗时，Lox函数、native函数和类的构造都会被记录
+type Profiler struct {
+	stack     []*frame
+	folded    map[string]time.Duration // 调用栈 -> 栈顶函数自身的耗时
+	functions map[string]*ProfileEntry
+	lines     map[int]*ProfileEntry
+	now       func() time.Time
+}
+
+// ProfileEntry 是一个函数或者一行代码的统计结果，Total包括了调用其它函数的耗时，Self不包括
+type ProfileEntry struct {
+	Name  string
+	Calls int
+	Total time.Duration
+	Self  time.Duration
+}
+
+type frame struct {
+	name     string
+	line     int
+	start    time.Time
+	children time.Duration
+}
+
+func NewProfiler() *Profiler {
+	return &Profiler{
+		folded:    make(map[string]time.Duration),
+		functions: make(map[string]*ProfileEntry),
+		lines:     make(map[int]*ProfileEntry),
+		now:       time.Now,
+	}
+}
+
+// SetProfiler 之后所有的函数调用都会被记录到profiler中，nil表示关闭
+func (i *Interpreter) SetProfiler(profiler *Profiler) {
+	i.profiler = profiler
+}
+
+// profile 在Call的开头调用，返回的函数需要在调用结束时执行，site是发起调用的token，nil的时候记为第0行
+func (i *Interpreter) profile(name string, site *token.Token) func() {
+	if i.profiler == nil {
+		return func() {}
+	}
+
+	line := 0
+	if site != nil {
+		line = site.Line
+	}
+
+	return i.profiler.enter(name, line)
+}
+
+func (p *Profiler) enter(name string, line int) func() {
+	f := &frame{name: name, line: line, start: p.now()}
+	p.stack = append(p.stack, f)
+
+	return func() {
+		p.exit(f)
+	}
+}
+
+func (p *Profiler) exit(f *frame) {
+	total := p.now().Sub(f.start)
+	self := total - f.children
+
+	names := make([]string, len(p.stack))
+	for n, f := range p.stack {
+		names[n] = f.name
+	}
+	p.folded[strings.Join(names, ";")] += self
+
+	p.stack = p.stack[:len(p.stack)-1]
+
+	// 递归调用的时候，函数和调用位置的总耗时只在最外层的调用中计算一次
+	var nestedFunction, nestedLine bool
+	for _, outer := range p.stack {
+		nestedFunction = nestedFunction || outer.name == f.name
+		nestedLine = nestedLine || outer.line == f.line
+	}
+	if len(p.stack) > 0 {
+		p.stack[len(p.stack)-1].children += total
+	}
+
+	entry := p.function(f.name)
+	entry.Calls++
+	entry.Self += self
+	if !nestedFunction {
+		entry.Total += total
+	}
+
+	entry = p.line(f.line)
+	entry.Calls++
+	entry.Self += self
+	if !nestedLine {
+		entry.Total += total
+	}
+}
+
+func (p *Profiler) function(name string) *ProfileEntry {
+	if entry, ok := p.functions[name]; ok {
+		return entry
+	}
+
+	entry := &ProfileEntry{Name: name}
+	p.functions[name] = entry
+
+	return entry
+}
+
+func (p *Profiler) line(line int) *ProfileEntry {
+	if entry, ok := p.lines[line]; ok {
+		return entry
+	}
+
+	entry := &ProfileEntry{Name: fmt.Sprintf("line %d", line)}
+	p.lines[line] = entry
+
+	return entry
+}
+
+// Functions 返回每个函数的统计结果，按自身耗时从高到低排列
+func (p *Profiler) Functions() []*ProfileEntry {
+	var entries []*ProfileEntry
+	for _, entry := range p.functions {
+		entries = append(entries, entry)
+	}
+
+	return sortEntries(entries)
+}
+
+// Lines 返回每个调用位置的统计结果，按总耗时从高到低排列
+func (p *Profiler) Lines() []*ProfileEntry {
+	var entries []*ProfileEntry
+	for _, entry := range p.lines {
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(a, b int) bool {
+		if entries[a].Total != entries[b].Total {
+			return entries[a].Total > entries[b].Total
+		}
+
+		return entries[a].Name < entries[b].Name
+	})
+
+	return entries
+}
+
+func sortEntries(entries []*ProfileEntry) []*ProfileEntry {
+	sort.Slice(entries, func(a, b int) bool {
+		if entries[a].Self != entries[b].Self {
+			return entries[a].Self > entries[b].Self
+		}
+
+		return entries[a].Name < entries[b].Name
+	})
+
+	return entries
+}
+
+// WriteFolded 以 flamegraph.pl 等工具使用的folded格式输出调用栈，每一行是 "a;b;c 耗时(微秒)"
+func (p *Profiler) WriteFolded(w io.Writer) error {
+	var stacks []string
+	for stack := range p.folded {
+		stacks = append(stacks, stack)
+	}
+	sort.Strings(stacks)
+
+	for _, stack := range stacks {
+		_, err := fmt.Fprintf(w, "%s %d\n", stack, p.folded[stack].Microseconds())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteSummary 输出自身耗时最高的n个函数和总耗时最高的n个调用位置
+func (p *Profiler) WriteSummary(w io.Writer, n int) {
+	writeEntries(w, "function", p.Functions(), n)
+	fmt.Fprintln(w)
+	writeEntries(w, "call site", p.Lines(), n)
+}
+
+func writeEntries(w io.Writer, title string, entries []*ProfileEntry, n int) {
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+
+	fmt.Fprintf(w, "%8s %12s %12s  %s\n", "calls", "self", "total", title)
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%8d %12v %12v  %s\n", entry.Calls, entry.Self, entry.Total, entry.Name)
+	}
+}
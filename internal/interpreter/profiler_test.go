@@ -0,0 +1,85 @@
+package interpreter_test
+
+import (
+	"GLox/internal/interpreter"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestProfiler(t *testing.T) {
+	source := `
+fun fib(n) {
+    if (n < 2) return n;
+    return fib(n - 1) + fib(n - 2);
+}
+class A {
+    init() {}
+}
+fib(5);
+A();
+clock();
+((n) => n)(1);`
+
+	i, stmts := prepare(t, source)
+	profiler := interpreter.NewProfiler()
+	i.SetProfiler(profiler)
+	if err := i.Interpret(stmts); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := make(map[string]int)
+	for _, entry := range profiler.Functions() {
+		calls[entry.Name] = entry.Calls
+	}
+	expected := map[string]int{"fib:2": 15, "A": 1, "init:7": 1, "clock": 1, "<lambda>:12": 1}
+	for name, n := range expected {
+		if calls[name] != n {
+			t.Errorf("expected %d calls of %s, but got %d", n, name, calls[name])
+		}
+	}
+
+	var folded bytes.Buffer
+	if err := profiler.WriteFolded(&folded); err != nil {
+		t.Fatal(err)
+	}
+	for _, stack := range []string{"fib:2 ", "fib:2;fib:2;fib:2 ", "A;init:7 ", "clock "} {
+		if !strings.Contains(folded.String(), "\n"+stack) && !strings.HasPrefix(folded.String(), stack) {
+			t.Errorf("missing stack %q in:\n%s", stack, folded.String())
+		}
+	}
+}
+
+// getter、setter、构造函数中的init和重载的运算符都记在发起调用的那一行
+func TestProfilerCallSites(t *testing.T) {
+	source := `class Point {
+    init(x) { this.x = x; }
+    double { return this.x * 2; }
+    set double(value) { this.x = value / 2; }
+    __add__(other) { return Point(this.x + other.x); }
+}
+var p = Point(1);
+var d = p.double;
+p.double = 4;
+var q = p + p;
+getattr(p, "double");`
+
+	i, stmts := prepare(t, source)
+	profiler := interpreter.NewProfiler()
+	i.SetProfiler(profiler)
+	if err := i.Interpret(stmts); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := make(map[string]int)
+	for _, entry := range profiler.Lines() {
+		calls[entry.Name] = entry.Calls
+	}
+	// 第7行和第5行是Point和它的init，第11行是getattr和它调用的getter
+	expected := map[string]int{"line 7": 2, "line 8": 1, "line 9": 1, "line 10": 1, "line 5": 2, "line 11": 2}
+	for line, n := range expected {
+		if calls[line] != n {
+			t.Errorf("expected %d calls on %s, but got %d (%v)", n, line, calls[line], calls)
+		}
+	}
+}
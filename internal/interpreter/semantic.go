@@ -2,8 +2,10 @@ package interpreter
 
 import (
 	le "GLox/internal/loxerror"
+	"GLox/internal/numeric"
 	parser2 "GLox/internal/parser"
 	"GLox/internal/scanner/token"
+	"errors"
 	"fmt"
 )
 
@@ -20,65 +22,45 @@ func (i *Interpreter) VisitBinaryExpr(expr *parser2.Binary) (interface{}, error)
 		return nil, err
 	}
 
-	switch expr.Operator.Type {
-	case token.MINUS:
-		err = checkNumberOperands(expr.Operator, lv, rv)
-		if err != nil {
-			return nil, err
-		}
-		return lv.(float64) - rv.(float64), nil
-	case token.STAR:
-		err = checkNumberOperands(expr.Operator, lv, rv)
-		if err != nil {
-			return nil, err
-		}
-		return lv.(float64) * rv.(float64), nil
-	case token.SLASH:
-		err = checkNumberOperands(expr.Operator, lv, rv)
+	return i.binary(expr.Operator, lv, rv)
+}
+
+// binary 对两个已经求值的操作数进行二元运算，复合赋值（如 +=）也复用这里的逻辑
+func (i *Interpreter) binary(operator *token.Token, lv, rv interface{}) (_ interface{}, err error) {
+	// 左侧操作数是实例的时候，优先调用它重载的运算符方法
+	if result, found, err := i.binaryOverload(operator, lv, rv); found {
+		return result, err
+	}
+
+	switch operator.Type {
+	case token.MINUS, token.STAR, token.SLASH:
+		err = checkNumberOperands(operator, lv, rv)
 		if err != nil {
 			return nil, err
 		}
-		return lv.(float64) / rv.(float64), nil
+		return arith(operator, lv, rv)
 	// 加法操作可以定义在数字和字符之上
 	case token.PLUS:
-		return doPlus(expr.Operator, lv, rv)
-	case token.GREATER:
-		err = checkNumberOperands(expr.Operator, lv, rv)
-		if err != nil {
-			return nil, err
+		result, err := doPlus(operator, lv, rv)
+		if s, ok := result.(string); ok && err == nil {
+			err = i.allocate(len(s))
 		}
-		return lv.(float64) > rv.(float64), nil
-	case token.GREATER_EQUAL:
-		err = checkNumberOperands(expr.Operator, lv, rv)
+		return result, err
+	case token.GREATER, token.GREATER_EQUAL, token.LESS, token.LESS_EQUAL:
+		err = checkNumberOperands(operator, lv, rv)
 		if err != nil {
 			return nil, err
 		}
-		return lv.(float64) >= rv.(float64), nil
-	case token.LESS:
-		err = checkNumberOperands(expr.Operator, lv, rv)
-		if err != nil {
-			return nil, err
-		}
-		return lv.(float64) < rv.(float64), nil
-	case token.LESS_EQUAL:
-		err = checkNumberOperands(expr.Operator, lv, rv)
-		if err != nil {
-			return nil, err
-		}
-		return lv.(float64) <= rv.(float64), nil
-	// == 和 != 运算的结果是bool类型
+		return compare(operator, lv, rv), nil
+	// 取模和位运算只能作用于整数，见integer.go
+	case token.PERCENT, token.AMPERSAND, token.PIPE, token.CARET, token.LESS_LESS, token.GREATER_GREATER:
+		return integerBinary(operator, lv, rv)
+	// == 和 != 运算的结果是bool类型，可以作用于任意类型的值
 	case token.BANG_EQUAL:
-		err = checkNumberOperands(expr.Operator, lv, rv)
-		if err != nil {
-			return nil, err
-		}
-		return !isEqual(lv, rv), nil
+		equal, err := i.equals(operator, lv, rv)
+		return !equal, err
 	case token.EQUAL_EQUAL:
-		err = checkNumberOperands(expr.Operator, lv, rv)
-		if err != nil {
-			return nil, err
-		}
-		return isEqual(lv, rv), nil
+		return i.equals(operator, lv, rv)
 	}
 	return nil, nil
 }
@@ -101,12 +83,26 @@ func (i *Interpreter) VisitUnaryExpr(expr *parser2.Unary) (interface{}, error) {
 
 	switch expr.Operator.Type {
 	case token.MINUS:
+		if instance, ok := rv.(*LoxInstance); ok {
+			if result, found, err := i.invokeSpecial(instance, negMethod, expr.Operator); found {
+				return result, err
+			}
+		}
+
 		err := checkNumberOperands(expr.Operator, rv)
 		if err != nil {
 			return nil, err
 		}
 
-		return -(rv.(float64)), nil
+		return numeric.Neg(rv), nil
+	case token.TILDE:
+		if instance, ok := rv.(*LoxInstance); ok {
+			if result, found, err := i.invokeSpecial(instance, invertMethod, expr.Operator); found {
+				return result, err
+			}
+		}
+
+		return invert(expr.Operator, rv)
 	case token.BANG:
 		return !isTruth(rv), nil
 	}
@@ -120,27 +116,44 @@ func (i *Interpreter) VisitVariableExpr(expr *parser2.Variable) (interface{}, er
 }
 
 func (i *Interpreter) VisitAssignExpr(expr *parser2.Assign) (interface{}, error) {
+	// 因为赋值也是一个表达式，所以这里返回所求的value
+	_, value, err := i.assignVariable(expr)
+
+	return value, err
+}
+
+// assignVariable 执行变量的（复合）赋值，返回赋值前后的值
+func (i *Interpreter) assignVariable(expr *parser2.Assign) (old, value interface{}, err error) {
+	if expr.Operator != nil {
+		old, err = i.lookUpVariable(expr.Name, expr)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// 计算Assign的语法树上的value节点
-	value, err := i.evaluate(expr.Value)
+	value, err = i.evaluate(expr.Value)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	if expr.Operator != nil {
+		value, err = i.binary(expr.Operator, old, value)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
-	/*
-		i.environment.assign(expr.Name, value)
 
-		// 因为赋值也是一个表达式，所以这里返回所求的value
-		return value
-	*/
 	if distance, ok := i.locals[expr]; ok {
 		i.environment.assignAt(distance, expr.Name, value)
 	} else {
 		err := i.globals.assign(expr.Name, value)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
-	return nil, nil
+	return old, value, nil
 }
 
 func (i *Interpreter) VisitLogicExpr(expr *parser2.Logic) (interface{}, error) {
@@ -149,16 +162,25 @@ func (i *Interpreter) VisitLogicExpr(expr *parser2.Logic) (interface{}, error) {
 		return nil, err
 	}
 
-	if expr.Operator.Type == token.OR {
+	switch expr.Operator.Type {
+	case token.OR:
 		if isTruth(left) {
+			i.branch(expr, 0)
 			return left, nil
 		}
-	} else {
+	case token.QUESTION_QUESTION:
+		if left != nil {
+			i.branch(expr, 0)
+			return left, nil
+		}
+	default:
 		if !isTruth(left) {
+			i.branch(expr, 0)
 			return left, nil
 		}
 	}
 
+	i.branch(expr, 1)
 	return i.evaluate(expr.Right)
 }
 
@@ -168,6 +190,9 @@ func (i *Interpreter) VisitCallExpr(expr *parser2.Call) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	if calleeI == nil && expr.Optional {
+		return nil, errShortCircuit
+	}
 
 	callee, ok := calleeI.(LoxCallable)
 	if !ok {
@@ -185,13 +210,25 @@ func (i *Interpreter) VisitCallExpr(expr *parser2.Call) (interface{}, error) {
 		args = append(args, value)
 	}
 
+	if err := i.checkCanceled(expr.Paren); err != nil {
+		return nil, err
+	}
+
 	// 判断实参和形参的个数是否相同
-	if len(args) != callee.Arity() {
+	if !acceptsArguments(callee, len(args)) {
 		//panic(le.NewRuntimeError(expr.Paren, fmt.Sprintf("Expect %d arguments buf got %d.", len(args), callee.Arity())))
 		return nil, le.NewRuntimeError(expr.Paren, fmt.Sprintf("Expect %d arguments buf got %d.", len(args), callee.Arity()))
 	}
 
-	return callee.Call(i, args)
+	result, err := callee.Call(i, expr.Paren, args)
+	switch e := err.(type) {
+	case nativeError:
+		return nil, le.NewRuntimeError(expr.Paren, string(e))
+	case assertionFailure:
+		return nil, le.NewAssertionError(expr.Paren, string(e))
+	}
+
+	return result, err
 }
 
 func (i *Interpreter) VisitGetExpr(expr *parser2.Get) (interface{}, error) {
@@ -199,38 +236,293 @@ func (i *Interpreter) VisitGetExpr(expr *parser2.Get) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	if object == nil && expr.Optional {
+		return nil, errShortCircuit
+	}
 
-	// object必须是一个Instance
-	instance, ok := object.(*LoxInstance)
-	if !ok {
-		//panic(le.NewRuntimeError(expr.Attribute, "Only instances have attributes."))
-		return nil, le.NewRuntimeError(expr.Attribute, "Only instances have attributes.")
+	// object必须是一个Instance，或者是访问静态成员的Class
+	switch object := object.(type) {
+	case *LoxInstance:
+		return object.Get(i, expr.Attribute)
+	case *LoxClass:
+		return object.Get(expr.Attribute)
+	case *LoxGenerator:
+		return object.Get(expr.Attribute)
 	}
 
-	return instance.Get(expr.Attribute)
+	//panic(le.NewRuntimeError(expr.Attribute, "Only instances have attributes."))
+	return nil, le.NewRuntimeError(expr.Attribute, "Only instances have attributes.")
 }
 
 func (i *Interpreter) VisitSetExpr(expr *parser2.Set) (interface{}, error) {
+	_, value, err := i.assignAttribute(expr)
+
+	return value, err
+}
+
+// assignAttribute 执行属性的（复合）赋值，返回赋值前后的值
+func (i *Interpreter) assignAttribute(expr *parser2.Set) (old, value interface{}, err error) {
 	// 计算等号左侧的表达式，找出要复制的属性
 	object, err := i.evaluate(expr.Object)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	instance, ok := object.(*LoxInstance)
 	if !ok {
+		if _, ok := object.(*LoxClass); ok {
+			return nil, nil, le.NewRuntimeError(expr.Attribute, "Can't assign to class attribute '"+expr.Attribute.Lexeme+"'.")
+		}
 		//panic(le.NewRuntimeError(expr.Attribute, "Only instances have attributes."))
-		return nil, le.NewRuntimeError(expr.Attribute, "Only instances have attributes.")
+		return nil, nil, le.NewRuntimeError(expr.Attribute, "Only instances have attributes.")
+	}
+
+	if expr.Operator != nil {
+		old, err = instance.Get(i, expr.Attribute)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	value, err = i.evaluate(expr.Value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if expr.Operator != nil {
+		value, err = i.binary(expr.Operator, old, value)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	err = instance.Set(i, expr.Attribute, value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return old, value, nil
+}
+
+func (i *Interpreter) VisitIndexExpr(expr *parser2.Index) (interface{}, error) {
+	object, err := i.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := i.evaluate(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.getIndex(expr.Bracket, object, index)
+}
+
+// getIndex 获取object[index]的值，object可以是list、map、字符串或者定义了__index__的实例
+func (i *Interpreter) getIndex(bracket *token.Token, object, index interface{}) (interface{}, error) {
+	switch object := object.(type) {
+	case *LoxInstance:
+		if result, found, err := i.invokeSpecial(object, indexMethod, bracket, index); found {
+			return result, err
+		}
+	case *LoxList:
+		n, err := checkIndex(bracket, index, len(object.elements))
+		if err != nil {
+			return nil, err
+		}
+
+		return object.elements[n], nil
+	case *LoxMap:
+		// 不存在的key对应的值是nil，可以和 ?? 一起使用
+		key, err := hashKey(bracket, index)
+		if err != nil {
+			return nil, err
+		}
+
+		return object.get(key), nil
+	case string:
+		n, err := checkIndex(bracket, index, len(object))
+		if err != nil {
+			return nil, err
+		}
+
+		return object[n : n+1], nil
+	}
+
+	return nil, le.NewRuntimeError(bracket, "Only lists, maps, strings and instances defining '__index__' can be indexed.")
+}
+
+// setIndex 执行object[index] = value，object可以是list、map或者定义了__setindex__的实例
+func (i *Interpreter) setIndex(bracket *token.Token, object, index, value interface{}) error {
+	switch object := object.(type) {
+	case *LoxInstance:
+		if _, found, err := i.invokeSpecial(object, setIndexMethod, bracket, index, value); found {
+			return err
+		}
+	case *LoxList:
+		n, err := checkIndex(bracket, index, len(object.elements))
+		if err != nil {
+			return err
+		}
+
+		object.elements[n] = value
+		return nil
+	case *LoxMap:
+		key, err := hashKey(bracket, index)
+		if err != nil {
+			return err
+		}
+
+		if object.set(key, index, value) {
+			return i.allocate(entrySize)
+		}
+		return nil
+	}
+
+	return le.NewRuntimeError(bracket, "Only lists, maps and instances defining '__setindex__' support index assignment.")
+}
+
+func (i *Interpreter) VisitIndexSetExpr(expr *parser2.IndexSet) (interface{}, error) {
+	_, value, err := i.assignIndex(expr)
+
+	return value, err
+}
+
+// assignIndex 执行下标的（复合）赋值，返回赋值前后的值
+func (i *Interpreter) assignIndex(expr *parser2.IndexSet) (old, value interface{}, err error) {
+	object, err := i.evaluate(expr.Object)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	index, err := i.evaluate(expr.Index)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if expr.Operator != nil {
+		old, err = i.getIndex(expr.Bracket, object, index)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	value, err = i.evaluate(expr.Value)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	value, err := i.evaluate(expr.Value)
+	if expr.Operator != nil {
+		value, err = i.binary(expr.Operator, old, value)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	err = i.setIndex(expr.Bracket, object, index, value)
 	if err != nil {
+		return nil, nil, err
+	}
+
+	return old, value, nil
+}
+
+func (i *Interpreter) VisitPostfixExpr(expr *parser2.Postfix) (old interface{}, err error) {
+	// 后缀表达式的值是赋值之前的旧值
+	switch target := expr.Target.(type) {
+	case *parser2.Assign:
+		old, _, err = i.assignVariable(target)
+	case *parser2.Set:
+		old, _, err = i.assignAttribute(target)
+	case *parser2.IndexSet:
+		old, _, err = i.assignIndex(target)
+	}
+
+	return old, err
+}
+
+func (i *Interpreter) VisitListExpr(expr *parser2.List) (interface{}, error) {
+	elements := make([]interface{}, 0, len(expr.Elements))
+	for _, element := range expr.Elements {
+		value, err := i.evaluate(element)
+		if err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, value)
+	}
+
+	if err := i.allocate(listSize + elementSize*len(elements)); err != nil {
 		return nil, err
 	}
 
-	instance.fields[expr.Attribute.Lexeme] = value
+	return NewLoxList(elements), nil
+}
+
+func (i *Interpreter) VisitMapExpr(expr *parser2.Map) (interface{}, error) {
+	m := NewLoxMap()
+	for n, keyExpr := range expr.Keys {
+		key, err := i.evaluate(keyExpr)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := i.evaluate(expr.Values[n])
+		if err != nil {
+			return nil, err
+		}
+
+		hashed, err := hashKey(expr.Brace, key)
+		if err != nil {
+			return nil, err
+		}
+
+		// 重复的key和依次赋值一样，后面的值覆盖前面的
+		m.set(hashed, key, value)
+	}
 
-	return value, nil
+	if err := i.allocate(listSize + entrySize*len(m.keys)); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (i *Interpreter) VisitConditionalExpr(expr *parser2.Conditional) (interface{}, error) {
+	condition, err := i.evaluate(expr.Condition)
+	if err != nil {
+		return nil, err
+	}
+
+	if isTruth(condition) {
+		i.branch(expr, 0)
+		return i.evaluate(expr.ThenBranch)
+	}
+
+	i.branch(expr, 1)
+	return i.evaluate(expr.ElseBranch)
+}
+
+// errShortCircuit 由 ?. 左侧为nil的Get或者Call返回，沿着调用链向上传递，直到OptionalChain把它变成nil
+var errShortCircuit = errors.New("short circuit")
+
+func (i *Interpreter) VisitOptionalChainExpr(expr *parser2.OptionalChain) (interface{}, error) {
+	value, err := i.evaluate(expr.Expression)
+	if err == errShortCircuit {
+		return nil, nil
+	}
+
+	return value, err
+}
+
+// VisitBadExpr 只有容错解析才会生成BadExpr，这样的语法树不能执行
+func (i *Interpreter) VisitBadExpr(expr *parser2.BadExpr) (interface{}, error) {
+	return nil, le.NewRuntimeError(expr.From, "Syntax error.")
+}
+
+func (i *Interpreter) VisitLambdaExpr(expr *parser2.Lambda) (interface{}, error) {
+	// 匿名函数和函数声明一样捕获当前的作用域，只是不会绑定到任何变量上
+	return NewLoxFunction(expr.Function, i.environment, false), nil
 }
 
 func (i *Interpreter) VisitThisExpr(expr *parser2.This) (interface{}, error) {
@@ -238,15 +530,19 @@ func (i *Interpreter) VisitThisExpr(expr *parser2.This) (interface{}, error) {
 }
 
 func (i *Interpreter) VisitSuperExpr(expr *parser2.Super) (interface{}, error) {
-	//superclass := i.lookUpVariable(expr.Keyword, expr).(*LoxClass)
-	superclassI, err := i.lookUpVariable(expr.Keyword, expr)
-	if err != nil {
-		return nil, err
+	distance, ok := i.locals[expr]
+	if !ok {
+		return nil, le.NewRuntimeError(expr.Keyword, "Can't use 'super' outside of a class.")
 	}
 
-	superclass := superclassI.(*LoxClass)
-	instance := NewLoxInstance(superclass)
+	superclass := i.environment.getAt(distance, "super").(*LoxClass)
+	// "this"的作用域位于"super"的下一层，方法需要绑定到当前的接收者上，而不是一个新的实例
+	instance := i.environment.getAt(distance-1, "this").(*LoxInstance)
+
 	method := superclass.findMethod(expr.Identifier.Lexeme)
+	if method == nil {
+		return nil, le.NewRuntimeError(expr.Identifier, "Undefined method '"+expr.Identifier.Lexeme+"' in superclass '"+superclass.name+"'.")
+	}
 
 	return method.bind(instance), nil
 }
@@ -279,6 +575,21 @@ func (i *Interpreter) VisitReturnStmt(stmt *parser2.ReturnStmt) (err error) {
 	panic(NewReturn(value))
 }
 
+// VisitYieldStmt 挂起当前的生成器，直到调用者需要下一个值，resolver保证yield只出现在函数中
+func (i *Interpreter) VisitYieldStmt(stmt *parser2.YieldStmt) (err error) {
+	var value interface{}
+	if stmt.Value != nil {
+		value, err = i.evaluate(stmt.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	i.generator.yield(i, value)
+
+	return nil
+}
+
 func (i *Interpreter) VisitPrintStmt(stmt *parser2.PrintStmt) error {
 	value, err := i.evaluate(stmt.Expr)
 	if err != nil {
@@ -286,9 +597,9 @@ func (i *Interpreter) VisitPrintStmt(stmt *parser2.PrintStmt) error {
 	}
 
 	// 需要打印计算的值
-	fmt.Printf("%v\n", value)
+	_, err = fmt.Fprintf(i.stdout, "%v\n", value)
 
-	return nil
+	return err
 }
 
 func (i *Interpreter) VisitVarDeclStmt(stmt *parser2.VarDeclStmt) (err error) {
@@ -317,8 +628,12 @@ func (i *Interpreter) VisitIfStmt(stmt *parser2.IfStmt) error {
 	}
 
 	if isTruth(condition) {
+		i.branch(stmt, 0)
 		return i.execute(stmt.ThenBranch)
-	} else if stmt.ElseBranch != nil {
+	}
+
+	i.branch(stmt, 1)
+	if stmt.ElseBranch != nil {
 		return i.execute(stmt.ElseBranch)
 	}
 
@@ -326,16 +641,66 @@ func (i *Interpreter) VisitIfStmt(stmt *parser2.IfStmt) error {
 }
 
 func (i *Interpreter) VisitWhileStmt(stmt *parser2.WhileStmt) error {
-	condition, err := i.evaluate(stmt.Condition)
+	for {
+		// 每一轮循环开始前都要重新计算条件表达式的值
+		condition, err := i.evaluate(stmt.Condition)
+		if err != nil {
+			return err
+		}
+
+		if !isTruth(condition) {
+			return nil
+		}
+
+		err = i.execute(stmt.Body)
+		if err != nil {
+			return err
+		}
+
+		// 循环回到开头之前检查执行是否被取消
+		if err := i.checkCanceled(stmt.Keyword); err != nil {
+			return err
+		}
+	}
+}
+
+func (i *Interpreter) VisitForInStmt(stmt *parser2.ForInStmt) error {
+	iterable, err := i.evaluate(stmt.Iterable)
 	if err != nil {
 		return err
 	}
 
-	for isTruth(condition) {
-		return i.execute(stmt.Body)
+	it, err := i.iterate(stmt.Keyword, iterable)
+	if err != nil {
+		return err
+	}
+	// 循环因为return或者错误提前退出时结束生成器，已经遍历完的生成器close不做任何事
+	if c, ok := it.(closer); ok {
+		defer c.close(i)
 	}
 
-	return nil
+	// 用一个block包住循环体，executeBlock会在循环体执行完（或者return）之后恢复作用域
+	body := parser2.NewBlockStmt([]parser2.Stmt{stmt.Body})
+	for {
+		value, ok, err := it.next(i)
+		if e, isNative := err.(nativeError); isNative {
+			return le.NewRuntimeError(stmt.Keyword, string(e))
+		}
+		if err != nil || !ok {
+			return err
+		}
+
+		// 每一轮循环都有一个新的作用域，闭包捕获的是这一轮的循环变量
+		env := NewEnvironment(i.environment)
+		env.define(stmt.Name, value)
+		if err := i.executeBlock(body, env); err != nil {
+			return err
+		}
+
+		if err := i.checkCanceled(stmt.Keyword); err != nil {
+			return err
+		}
+	}
 }
 
 func (i *Interpreter) VisitClassDeclStmt(stmt *parser2.ClassDeclStmt) error {
@@ -355,18 +720,70 @@ func (i *Interpreter) VisitClassDeclStmt(stmt *parser2.ClassDeclStmt) error {
 	}
 
 	i.environment.define(stmt.Name, nil)
+	// 类级别的常量在类声明所在的作用域中求值
+	var fields = make(map[string]interface{})
+	for _, field := range stmt.Fields {
+		var value interface{}
+		if field.Initializer != nil {
+			var err error
+			value, err = i.evaluate(field.Initializer)
+			if err != nil {
+				return err
+			}
+		}
+
+		fields[field.Name.Lexeme] = value
+	}
+
+	// trait中的方法先被复制到类中，类自己定义的方法可以覆盖它们
+	var methods = make(map[string]*LoxFunction)
+	var providers = make(map[string]*LoxTrait)
+	var traits []*LoxTrait
+	for _, variable := range stmt.Traits {
+		value, err := i.evaluate(variable)
+		if err != nil {
+			return err
+		}
+
+		trait, ok := value.(*LoxTrait)
+		if !ok {
+			return le.NewRuntimeError(variable.Name, "'"+variable.Name.Lexeme+"' is not a trait.")
+		}
+
+		traits = append(traits, trait)
+		for name, method := range trait.methods {
+			if other, exist := providers[name]; exist && other != trait && !stmt.DefinesMethod(name) {
+				return le.NewRuntimeError(variable.Name, "Method '"+name+"' is provided by both trait '"+other.name+"' and '"+trait.name+"'.")
+			}
+
+			providers[name] = trait
+			methods[name] = method
+		}
+	}
+
 	// "super"的作用域位于methods的上层
 	if superclass != nil {
 		i.environment = NewEnvironment(i.environment)
 		i.environment.defineLiteral("super", superclass)
 	}
 	// methods
-	var methods = make(map[string]*LoxFunction)
 	for _, method := range stmt.Methods {
 		methods[method.Name.Lexeme] = NewLoxFunction(method, i.environment, method.Name.Lexeme == "init")
 	}
 
 	class := NewLoxClass(stmt.Name.Lexeme, superclass, methods)
+	class.traits = traits
+	class.fields = fields
+	for _, method := range stmt.ClassMethods {
+		class.classMethods[method.Name.Lexeme] = NewLoxFunction(method, i.environment, false)
+	}
+	for _, getter := range stmt.Getters {
+		class.getters[getter.Name.Lexeme] = NewLoxFunction(getter, i.environment, false)
+	}
+	for _, setter := range stmt.Setters {
+		class.setters[setter.Name.Lexeme] = NewLoxFunction(setter, i.environment, false)
+	}
+
 	if superclass != nil {
 		// 切换回原来的scoop
 		i.environment = i.environment.enclosing
@@ -374,3 +791,18 @@ func (i *Interpreter) VisitClassDeclStmt(stmt *parser2.ClassDeclStmt) error {
 
 	return i.environment.assign(stmt.Name, class)
 }
+
+func (i *Interpreter) VisitTraitDeclStmt(stmt *parser2.TraitDeclStmt) error {
+	var methods = make(map[string]*LoxFunction)
+	for _, method := range stmt.Methods {
+		methods[method.Name.Lexeme] = NewLoxFunction(method, i.environment, false)
+	}
+
+	i.environment.define(stmt.Name, NewLoxTrait(stmt.Name.Lexeme, methods))
+
+	return nil
+}
+
+func (i *Interpreter) VisitBadStmt(stmt *parser2.BadStmt) error {
+	return le.NewRuntimeError(stmt.From, "Syntax error.")
+}
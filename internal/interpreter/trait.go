@@ -0,0 +1,15 @@
+package interpreter
+
+// LoxTrait is a named set of methods that can be mixed into classes with "with".
+type LoxTrait struct {
+	name    string
+	methods map[string]*LoxFunction
+}
+
+func NewLoxTrait(name string, methods map[string]*LoxFunction) *LoxTrait {
+	return &LoxTrait{name: name, methods: methods}
+}
+
+func (lt *LoxTrait) String() string {
+	return "<trait " + lt.name + ">"
+}
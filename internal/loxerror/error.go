@@ -3,6 +3,7 @@ package loxerror
 import (
 	"GLox/internal/scanner/token"
 	"fmt"
+	"strings"
 )
 
 var (
@@ -20,11 +21,32 @@ func NewParseError(token *token.Token, message string) *ParseError {
 }
 
 func (e *ParseError) Error() string {
-	HadError = true
-	if e.token.Type == token.EOF {
-		return fmt.Sprintf("[parse error] line %d at EOF: %s", e.token.Line, e.message)
+	if e.token.Column > 0 {
+		return fmt.Sprintf("[parse error] line %d:%d: %s", e.token.Line, e.token.Column, e.message)
 	}
-	return fmt.Sprintf("[parse error] line %d at '%s': %s", e.token.Line, e.token.Lexeme, e.message)
+	return fmt.Sprintf("[parse error] line %d: %s", e.token.Line, e.message)
+}
+
+// Token 返回出错位置的token
+func (e *ParseError) Token() *token.Token {
+	return e.token
+}
+
+func (e *ParseError) Message() string {
+	return e.message
+}
+
+// ParseErrors 是一次解析中遇到的所有错误，按出现的顺序排列
+type ParseErrors []*ParseError
+
+// Error 每行一个错误
+func (e ParseErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "\n")
 }
 
 // #########################
@@ -41,3 +63,57 @@ func NewRuntimeError(token *token.Token, message string) *RuntimeError {
 func (r *RuntimeError) Error() string {
 	return fmt.Sprintf("Runtime error at line %d : %s", r.token.Line, r.message)
 }
+
+// #########################
+
+// AssertionError 由assert系列native函数抛出，表示Lox测试中的断言失败，和RuntimeError区分开
+type AssertionError struct {
+	token   *token.Token
+	message string
+}
+
+func NewAssertionError(token *token.Token, message string) *AssertionError {
+	return &AssertionError{token: token, message: message}
+}
+
+func (a *AssertionError) Error() string {
+	return fmt.Sprintf("Assertion failed at line %d : %s", a.token.Line, a.message)
+}
+
+// #########################
+
+// LimitError 表示脚本超出了interpreter的执行限制。它不是RuntimeError，Lox代码中的assertThrows不会捕获它，
+// 宿主程序可以通过 errors.As 把它和脚本自身的错误区分开
+type LimitError struct {
+	Limit   string // 超出的限制："steps"、"timeout"、"call depth"或者"memory"
+	message string
+}
+
+func NewLimitError(limit, message string) *LimitError {
+	return &LimitError{Limit: limit, message: message}
+}
+
+func (l *LimitError) Error() string {
+	return fmt.Sprintf("Limit exceeded (%s) : %s", l.Limit, l.message)
+}
+
+// #########################
+
+// CancelError 表示脚本的执行被宿主程序通过context取消，它包装了context返回的错误，
+// 所以可以用 errors.Is(err, context.Canceled) 判断
+type CancelError struct {
+	Line int // 停止执行的位置
+	err  error
+}
+
+func NewCancelError(token *token.Token, err error) *CancelError {
+	return &CancelError{Line: token.Line, err: err}
+}
+
+func (c *CancelError) Error() string {
+	return fmt.Sprintf("Execution canceled at line %d : %v", c.Line, c.err)
+}
+
+func (c *CancelError) Unwrap() error {
+	return c.err
+}
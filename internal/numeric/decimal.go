@@ -0,0 +1,196 @@
+package numeric
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DivisionScale 是除不尽时商保留的小数位数
+const DivisionScale = 20
+
+// maxExponent 限制字面量中指数的大小，避免 1e999999999d 这样的字面量占用大量内存
+const maxExponent = 1000
+
+// Decimal 是精确的十进制小数，值为 unscaled × 10^-scale，scale不小于0。
+// 和float64不同，0.1d + 0.2d 的结果就是0.3，适合表示金额。scale会保留字面量中的位数，所以 1.10d 打印为1.10
+type Decimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+// NewDecimal 返回 unscaled × 10^-scale，scale为负数时会转换成整数
+func NewDecimal(unscaled *big.Int, scale int) Decimal {
+	if scale < 0 {
+		unscaled = new(big.Int).Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+
+	return Decimal{unscaled: unscaled, scale: scale}
+}
+
+// ParseDecimal 解析 123、1.10 或者 1.5e-3 形式的文本
+func ParseDecimal(text string) (Decimal, bool) {
+	mantissa, exponent := text, 0
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		e, err := strconv.Atoi(text[i+1:])
+		if err != nil || e > maxExponent || e < -maxExponent {
+			return Decimal{}, false
+		}
+		mantissa, exponent = text[:i], e
+	}
+
+	scale := 0
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		scale = len(mantissa) - i - 1
+		mantissa = mantissa[:i] + mantissa[i+1:]
+	}
+
+	unscaled, ok := new(big.Int).SetString(mantissa, 10)
+	if !ok {
+		return Decimal{}, false
+	}
+
+	return NewDecimal(unscaled, scale-exponent), true
+}
+
+// decimalOf 把整数转换成scale为0的Decimal
+func decimalOf(n *big.Int) Decimal {
+	return Decimal{unscaled: n, scale: 0}
+}
+
+func (d Decimal) String() string {
+	s := new(big.Int).Abs(d.unscaled).String()
+	if d.scale > 0 {
+		if len(s) <= d.scale {
+			s = strings.Repeat("0", d.scale-len(s)+1) + s
+		}
+		s = s[:len(s)-d.scale] + "." + s[len(s)-d.scale:]
+	}
+	if d.unscaled.Sign() < 0 {
+		s = "-" + s
+	}
+
+	return s
+}
+
+// Rat 返回和d相等的分数
+func (d Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.unscaled, pow10(d.scale))
+}
+
+// Float64 返回最接近d的float64
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// Int 返回d的整数值，d有非零的小数部分时ok为false
+func (d Decimal) Int() (n *big.Int, ok bool) {
+	q, r := new(big.Int).QuoRem(d.unscaled, pow10(d.scale), new(big.Int))
+
+	return q, r.Sign() == 0
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.unscaled), scale: d.scale}
+}
+
+func (d Decimal) Sign() int {
+	return d.unscaled.Sign()
+}
+
+func (d Decimal) Add(other Decimal) Decimal {
+	x, y, scale := align(d, other)
+	return Decimal{unscaled: x.Add(x, y), scale: scale}
+}
+
+func (d Decimal) Sub(other Decimal) Decimal {
+	x, y, scale := align(d, other)
+	return Decimal{unscaled: x.Sub(x, y), scale: scale}
+}
+
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.unscaled, other.unscaled), scale: d.scale + other.scale}
+}
+
+// Quo 计算 d / other，other不能为0。商是有限小数时结果是精确的，小数位数至少是 d.scale - other.scale
+// （所以 1.10d / 1 仍然是1.10），否则四舍六入五成双地保留DivisionScale位小数
+func (d Decimal) Quo(other Decimal) Decimal {
+	q := new(big.Rat).Quo(d.Rat(), other.Rat())
+
+	scale, exact := terminatingScale(q.Denom())
+	if !exact {
+		scale = DivisionScale
+	}
+	if ideal := d.scale - other.scale; scale < ideal {
+		scale = ideal
+	}
+
+	return roundRat(q, scale)
+}
+
+func (d Decimal) Cmp(other Decimal) int {
+	x, y, _ := align(d, other)
+	return x.Cmp(y)
+}
+
+// align 把两个Decimal转换成相同的scale，返回新分配的unscaled
+func align(a, b Decimal) (x, y *big.Int, scale int) {
+	x, y = new(big.Int).Set(a.unscaled), new(big.Int).Set(b.unscaled)
+	switch {
+	case a.scale < b.scale:
+		x.Mul(x, pow10(b.scale-a.scale))
+		return x, y, b.scale
+	case a.scale > b.scale:
+		y.Mul(y, pow10(a.scale-b.scale))
+	}
+
+	return x, y, a.scale
+}
+
+// terminatingScale 分母只有因子2和5的分数是有限小数，返回需要的小数位数
+func terminatingScale(denominator *big.Int) (scale int, ok bool) {
+	d := new(big.Int).Set(denominator)
+	twos, fives := 0, 0
+	for d.Bit(0) == 0 && d.Sign() != 0 {
+		d.Rsh(d, 1)
+		twos++
+	}
+	five, r := big.NewInt(5), new(big.Int)
+	for {
+		q, m := new(big.Int).QuoRem(d, five, r)
+		if m.Sign() != 0 {
+			break
+		}
+		d = q
+		fives++
+	}
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return 0, false
+	}
+
+	if twos > fives {
+		return twos, true
+	}
+	return fives, true
+}
+
+// roundRat 把分数四舍六入五成双到scale位小数
+func roundRat(q *big.Rat, scale int) Decimal {
+	n := new(big.Int).Mul(q.Num(), pow10(scale))
+	quotient, remainder := new(big.Int).QuoRem(n, q.Denom(), new(big.Int))
+
+	// 比较 2|r| 和分母，决定是否远离0进位
+	twice := new(big.Int).Abs(remainder)
+	twice.Lsh(twice, 1)
+	if c := twice.Cmp(q.Denom()); c > 0 || (c == 0 && quotient.Bit(0) == 1) {
+		quotient.Add(quotient, big.NewInt(int64(n.Sign())))
+	}
+
+	return Decimal{unscaled: quotient, scale: scale}
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,263 @@
+// Package numeric 实现Lox的数字类型：float64、任意精度的整数（*big.Int）和精确的十进制小数（Decimal）。
+//
+// 两个不同类型的数字运算时，按照 整数 < Decimal < float64 的顺序把两个操作数提升到较高的类型，
+// 所以精确的数字只要和float64运算，结果就是float64。整数之间的除法能整除时结果仍然是整数，否则是Decimal。
+// 不同类型的数字按照精确的数值比较大小和相等，1 == 1.0d 的结果为true，而float64的0.1并不精确地等于0.1，
+// 所以 0.1 == 0.1d 的结果为false。这样相等是可传递的，map的key也可以按照数值区分
+package numeric
+
+import (
+	"GLox/internal/scanner/token"
+	"errors"
+	"math"
+	"math/big"
+)
+
+// Mode 决定没有后缀的数字字面量的类型，带 'd' 后缀的字面量（如 1.10d）总是Decimal
+type Mode int
+
+const (
+	// Float 所有的字面量都是float64，和之前的行为相同
+	Float Mode = iota
+	// Exact 整数字面量是*big.Int，带小数点或者指数的字面量是Decimal
+	Exact
+)
+
+var modeNames = map[Mode]string{Float: "float", Exact: "exact"}
+
+// ParseMode 解析命令行参数或者pragma中的模式名
+func ParseMode(name string) (Mode, bool) {
+	for mode, n := range modeNames {
+		if n == name {
+			return mode, true
+		}
+	}
+
+	return Float, false
+}
+
+func (m Mode) String() string {
+	return modeNames[m]
+}
+
+// Set 实现flag.Value，可以直接作为命令行参数
+func (m *Mode) Set(name string) error {
+	mode, ok := ParseMode(name)
+	if !ok {
+		return errors.New("number mode must be float or exact")
+	}
+	*m = mode
+
+	return nil
+}
+
+// MaxExactInteger 是float64可以精确表示所有比它小的整数的上限 2^53，
+// Float模式下超过它的整数字面量和位运算结果会报错，而不是悄悄地丢失精度
+const MaxExactInteger = 1 << 53
+
+// ErrDivisionByZero 精确的数字除以0时返回，float64除以0的结果是Inf
+var ErrDivisionByZero = errors.New("Division by zero.")
+
+// kind 是数字在提升顺序中的位置
+type kind int
+
+const (
+	notNumber kind = iota - 1
+	integer
+	decimal
+	float
+)
+
+func kindOf(v interface{}) kind {
+	switch v.(type) {
+	case *big.Int:
+		return integer
+	case Decimal:
+		return decimal
+	case float64:
+		return float
+	}
+
+	return notNumber
+}
+
+// IsNumber 判断v是不是三种数字类型之一
+func IsNumber(v interface{}) bool {
+	return kindOf(v) != notNumber
+}
+
+// Arith 计算两个数字的 + - * /，op是对应的token类型
+func Arith(op token.TokenType, a, b interface{}) (interface{}, error) {
+	switch promote(a, b) {
+	case float:
+		x, y := Float64(a), Float64(b)
+		switch op {
+		case token.PLUS:
+			return x + y, nil
+		case token.MINUS:
+			return x - y, nil
+		case token.STAR:
+			return x * y, nil
+		case token.SLASH:
+			return x / y, nil
+		}
+	case decimal:
+		return arithDecimal(op, toDecimal(a), toDecimal(b))
+	case integer:
+		x, y := a.(*big.Int), b.(*big.Int)
+		switch op {
+		case token.PLUS:
+			return new(big.Int).Add(x, y), nil
+		case token.MINUS:
+			return new(big.Int).Sub(x, y), nil
+		case token.STAR:
+			return new(big.Int).Mul(x, y), nil
+		case token.SLASH:
+			if y.Sign() == 0 {
+				return nil, ErrDivisionByZero
+			}
+			if q, r := new(big.Int).QuoRem(x, y, new(big.Int)); r.Sign() == 0 {
+				return q, nil
+			}
+			return decimalOf(x).Quo(decimalOf(y)), nil
+		}
+	}
+
+	return nil, nil
+}
+
+func arithDecimal(op token.TokenType, x, y Decimal) (interface{}, error) {
+	switch op {
+	case token.PLUS:
+		return x.Add(y), nil
+	case token.MINUS:
+		return x.Sub(y), nil
+	case token.STAR:
+		return x.Mul(y), nil
+	case token.SLASH:
+		if y.Sign() == 0 {
+			return nil, ErrDivisionByZero
+		}
+		return x.Quo(y), nil
+	}
+
+	return nil, nil
+}
+
+// Compare 比较两个数字的大小，和float64的NaN比较时ok为false。
+// 精确的数字和float64比较时不提升成float64，而是和float64的精确值比较，否则 2^53+1 会等于 2^53 的float64
+func Compare(a, b interface{}) (c int, ok bool) {
+	switch promote(a, b) {
+	case float:
+		x, y := Float64(a), Float64(b)
+		if math.IsNaN(x) || math.IsNaN(y) {
+			return 0, false
+		}
+		if kindOf(a) != kindOf(b) {
+			return compareExact(a, b), true
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	case decimal:
+		return toDecimal(a).Cmp(toDecimal(b)), true
+	}
+
+	return a.(*big.Int).Cmp(b.(*big.Int)), true
+}
+
+// compareExact 按照精确的数值比较一个float64和一个精确的数字，float64不能是NaN
+func compareExact(a, b interface{}) int {
+	// 精确的数字都是有限的，Inf比它们都大或者都小
+	if x, ok := a.(float64); ok && math.IsInf(x, 0) {
+		return int(math.Copysign(1, x))
+	}
+	if y, ok := b.(float64); ok && math.IsInf(y, 0) {
+		return -int(math.Copysign(1, y))
+	}
+
+	return Rat(a).Cmp(Rat(b))
+}
+
+// Rat 返回有限的数字精确的值，float64按照它二进制的值转换，比如0.1对应的不是1/10
+func Rat(v interface{}) *big.Rat {
+	switch v := v.(type) {
+	case *big.Int:
+		return new(big.Rat).SetInt(v)
+	case Decimal:
+		return v.Rat()
+	}
+
+	return new(big.Rat).SetFloat64(v.(float64))
+}
+
+// Equal 判断两个数字的值是否相等
+func Equal(a, b interface{}) bool {
+	c, ok := Compare(a, b)
+	return ok && c == 0
+}
+
+// Neg 返回 -v
+func Neg(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *big.Int:
+		return new(big.Int).Neg(v)
+	case Decimal:
+		return v.Neg()
+	}
+
+	return -v.(float64)
+}
+
+// Int 返回整数值的数字对应的*big.Int，有小数部分、Inf和NaN的时候ok为false
+func Int(v interface{}) (n *big.Int, ok bool) {
+	switch v := v.(type) {
+	case *big.Int:
+		return v, true
+	case Decimal:
+		return v.Int()
+	case float64:
+		if math.IsInf(v, 0) || v != math.Trunc(v) {
+			return nil, false
+		}
+		n, _ = big.NewFloat(v).Int(nil)
+		return n, true
+	}
+
+	return nil, false
+}
+
+// Float64 返回最接近v的float64
+func Float64(v interface{}) float64 {
+	switch v := v.(type) {
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f
+	case Decimal:
+		return v.Float64()
+	}
+
+	return v.(float64)
+}
+
+// promote 返回两个操作数提升之后的类型
+func promote(a, b interface{}) kind {
+	ka, kb := kindOf(a), kindOf(b)
+	if ka > kb {
+		return ka
+	}
+
+	return kb
+}
+
+func toDecimal(v interface{}) Decimal {
+	if n, ok := v.(*big.Int); ok {
+		return decimalOf(n)
+	}
+
+	return v.(Decimal)
+}
@@ -0,0 +1,118 @@
+package numeric
+
+import (
+	"GLox/internal/scanner/token"
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func parse(t *testing.T, text string) Decimal {
+	t.Helper()
+
+	d, ok := ParseDecimal(text)
+	if !ok {
+		t.Fatalf("failed to parse %q", text)
+	}
+
+	return d
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := map[string]string{
+		"1.10":   "1.10",
+		"-0.05":  "-0.05",
+		"1e3":    "1000",
+		"1.5e-3": "0.0015",
+		"12.5E1": "125",
+		".5":     "0.5",
+	}
+	for text, want := range tests {
+		if got := parse(t, text).String(); got != want {
+			t.Errorf("%s: got %s, want %s", text, got, want)
+		}
+	}
+
+	for _, text := range []string{"", "1.2.3", "1e", "1e99999"} {
+		if _, ok := ParseDecimal(text); ok {
+			t.Errorf("%q: expected an error", text)
+		}
+	}
+}
+
+func TestArith(t *testing.T) {
+	tests := []struct {
+		op   token.TokenType
+		a, b interface{}
+		want string
+	}{
+		{token.PLUS, big.NewInt(1), big.NewInt(2), "3"},
+		{token.SLASH, big.NewInt(7), big.NewInt(2), "3.5"},
+		{token.SLASH, big.NewInt(-8), big.NewInt(2), "-4"},
+		{token.SLASH, big.NewInt(2), big.NewInt(3), "0.66666666666666666667"},
+		{token.SLASH, big.NewInt(-2), big.NewInt(3), "-0.66666666666666666667"},
+		{token.PLUS, parse(t, "0.1"), parse(t, "0.2"), "0.3"},
+		{token.MINUS, parse(t, "1.10"), big.NewInt(1), "0.10"},
+		{token.STAR, parse(t, "1.5"), parse(t, "1.5"), "2.25"},
+		{token.SLASH, parse(t, "1.10"), big.NewInt(1), "1.10"},
+		{token.SLASH, parse(t, "1"), parse(t, "8"), "0.125"},
+		// 有限小数不受DivisionScale的限制
+		{token.SLASH, parse(t, "0.00000000000000000005"), big.NewInt(2), "0.000000000000000000025"},
+		{token.SLASH, big.NewInt(1), parse(t, "3.0"), "0.33333333333333333333"},
+		{token.PLUS, parse(t, "0.5"), 0.25, "0.75"},
+		{token.PLUS, big.NewInt(1), 0.5, "1.5"},
+	}
+
+	for _, test := range tests {
+		got, err := Arith(test.op, test.a, test.b)
+		if err != nil || fmt.Sprint(got) != test.want {
+			t.Errorf("%v %v %v: got %v (%v), want %s", test.a, test.op, test.b, got, err, test.want)
+		}
+	}
+
+	// 精确的数字和float64运算的结果是float64
+	if got, _ := Arith(token.PLUS, parse(t, "0.5"), 0.25); kindOf(got) != float {
+		t.Errorf("got %T, want float64", got)
+	}
+	if _, err := Arith(token.SLASH, big.NewInt(1), big.NewInt(0)); err != ErrDivisionByZero {
+		t.Errorf("got %v, want division by zero", err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	if !Equal(big.NewInt(1), 1.0) || !Equal(parse(t, "1.10"), parse(t, "1.1")) || !Equal(big.NewInt(2), parse(t, "2.00")) {
+		t.Error("expected numbers of different types to be equal")
+	}
+	if c, ok := Compare(parse(t, "0.1"), big.NewInt(1)); !ok || c != -1 {
+		t.Errorf("got %d, want -1", c)
+	}
+
+	// 精确的数字和float64按照float64精确的值比较
+	big53 := new(big.Int).Lsh(big.NewInt(1), 53)
+	if Equal(parse(t, "0.1"), 0.1) || Equal(new(big.Int).Add(big53, big.NewInt(1)), float64(1<<53)) || !Equal(big53, float64(1<<53)) {
+		t.Error("expected numbers to be compared by their exact values")
+	}
+	if c, _ := Compare(new(big.Int).Lsh(big53, 1000), math.Inf(1)); c != -1 {
+		t.Errorf("got %d, want a big integer to be less than +Inf", c)
+	}
+
+	var nan float64
+	nan = nan / nan
+	if _, ok := Compare(nan, big.NewInt(1)); ok {
+		t.Error("expected NaN to be unordered")
+	}
+}
+
+func TestInt(t *testing.T) {
+	for _, v := range []interface{}{3.0, big.NewInt(3), parse(t, "3.00")} {
+		if n, ok := Int(v); !ok || n.Int64() != 3 {
+			t.Errorf("%v: got %v, want 3", v, n)
+		}
+	}
+	for _, v := range []interface{}{3.5, parse(t, "3.01"), "3"} {
+		if _, ok := Int(v); ok {
+			t.Errorf("%v: expected not an integer", v)
+		}
+	}
+}
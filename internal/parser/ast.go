@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"GLox/internal/scanner/token"
+)
+
+// 语法树节点的定义在 ast.schema 中，expr.go、statement.go、visitor.go、base_visitor.go 和 walk.go 都是生成的代码，
+// 这里是没有办法生成的部分
+//go:generate go run ../../tools/ast -schema ast.schema -o .
+
+// Node 是所有语法树节点的公共接口，Expr和Stmt都实现了它。Pos是节点第一个字符的位置，End是最后一个字符之后的位置
+type Node interface {
+	Pos() token.Position
+	End() token.Position
+}
+
+func NewLiteral(value interface{}) Expr {
+	return &Literal{Value: value}
+}
+
+func NewTokenLiteral(value interface{}, token *token.Token) Expr {
+	return &Literal{Value: value, Token: token}
+}
+
+// DefinesMethod 判断类自身（不包括superclass和trait）是否定义了名为name的方法
+func (c *ClassDeclStmt) DefinesMethod(name string) bool {
+	for _, method := range c.Methods {
+		if method.Name.Lexeme == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func NewFuncDeclStmt(keyword, name *token.Token, params []*token.Token, body *BlockStmt) *FuncDeclStmt {
+	return &FuncDeclStmt{Keyword: keyword, Name: name, Params: params, Body: body, Generator: hasYield(body)}
+}
+
+// hasYield 判断函数体中是否有yield，嵌套的函数和lambda中的yield属于它们自己
+func hasYield(body *BlockStmt) bool {
+	if body == nil {
+		return false
+	}
+
+	generator := false
+	Walk(body, func(node Node) bool {
+		switch node.(type) {
+		case *YieldStmt:
+			generator = true
+		case *FuncDeclStmt, *Lambda:
+			return false
+		}
+		return !generator
+	})
+
+	return generator
+}
@@ -0,0 +1,75 @@
+// Code generated by tools/ast from ast.schema; DO NOT EDIT.
+
+package parser
+
+// BaseVisitor 实现了ExprVisitor和StmtVisitor的所有方法，但是什么都不做。
+// 嵌入到其他结构体中之后，只需要实现关心的那几个方法
+type BaseVisitor struct{}
+
+func (BaseVisitor) VisitBinaryExpr(expr *Binary) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitGroupingExpr(expr *Grouping) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitLiteralExpr(expr *Literal) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitUnaryExpr(expr *Unary) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitVariableExpr(expr *Variable) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitAssignExpr(expr *Assign) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitLogicExpr(expr *Logic) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitCallExpr(expr *Call) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitGetExpr(expr *Get) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitSetExpr(expr *Set) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitThisExpr(expr *This) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitSuperExpr(expr *Super) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitIndexExpr(expr *Index) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitIndexSetExpr(expr *IndexSet) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitPostfixExpr(expr *Postfix) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitLambdaExpr(expr *Lambda) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitListExpr(expr *List) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitMapExpr(expr *Map) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitConditionalExpr(expr *Conditional) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitOptionalChainExpr(expr *OptionalChain) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitBadExpr(expr *BadExpr) (interface{}, error) { return nil, nil }
+
+func (BaseVisitor) VisitExprStmt(stmt *ExprStmt) error { return nil }
+
+func (BaseVisitor) VisitFuncDeclStmt(stmt *FuncDeclStmt) error { return nil }
+
+func (BaseVisitor) VisitClassDeclStmt(stmt *ClassDeclStmt) error { return nil }
+
+func (BaseVisitor) VisitTraitDeclStmt(stmt *TraitDeclStmt) error { return nil }
+
+func (BaseVisitor) VisitReturnStmt(stmt *ReturnStmt) error { return nil }
+
+func (BaseVisitor) VisitYieldStmt(stmt *YieldStmt) error { return nil }
+
+func (BaseVisitor) VisitPrintStmt(stmt *PrintStmt) error { return nil }
+
+func (BaseVisitor) VisitVarDeclStmt(stmt *VarDeclStmt) error { return nil }
+
+func (BaseVisitor) VisitBlockStmt(stmt *BlockStmt) error { return nil }
+
+func (BaseVisitor) VisitIfStmt(stmt *IfStmt) error { return nil }
+
+func (BaseVisitor) VisitWhileStmt(stmt *WhileStmt) error { return nil }
+
+func (BaseVisitor) VisitForInStmt(stmt *ForInStmt) error { return nil }
+
+func (BaseVisitor) VisitBadStmt(stmt *BadStmt) error { return nil }
@@ -0,0 +1,214 @@
+package parser
+
+import (
+	"GLox/internal/scanner/token"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// dumpNode 是语法树节点的通用表示，由节点结构体的字段通过反射得到，新增的节点类型不需要修改这里。
+// JSON和DOT格式的输出都基于它
+type dumpNode struct {
+	kind   string
+	line   int
+	fields []dumpField
+}
+
+type dumpField struct {
+	name  string
+	value interface{} // *dumpNode、[]interface{}、dumpToken 或者字面量的值
+}
+
+type dumpToken struct {
+	Type    string      `json:"type"`
+	Lexeme  string      `json:"lexeme"`
+	Literal interface{} `json:"literal,omitempty"`
+	Line    int         `json:"line"`
+	Column  int         `json:"column"`
+}
+
+var (
+	exprType  = reflect.TypeOf((*Expr)(nil)).Elem()
+	stmtType  = reflect.TypeOf((*Stmt)(nil)).Elem()
+	tokenType = reflect.TypeOf((*token.Token)(nil))
+)
+
+func newDumpNode(node interface{}) *dumpNode {
+	v := reflect.ValueOf(node).Elem()
+	n := &dumpNode{kind: v.Type().Name()}
+	switch node := node.(type) {
+	case Stmt:
+		n.line = node.Line()
+	case Expr:
+		n.line = exprLine(node)
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.IsExported() {
+			n.fields = append(n.fields, dumpField{name: lowerFirst(field.Name), value: dumpValue(v.Field(i))})
+		}
+	}
+
+	return n
+}
+
+func dumpValue(v reflect.Value) interface{} {
+	switch {
+	case v.Type() == tokenType:
+		if v.IsNil() {
+			return nil
+		}
+		t := v.Interface().(*token.Token)
+		return dumpToken{Type: t.Type.String(), Lexeme: t.Lexeme, Literal: t.Literal, Line: t.Line, Column: t.Column}
+	case v.Kind() == reflect.Slice:
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = dumpValue(v.Index(i))
+		}
+		return values
+	case v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Implements(exprType) || v.Type().Implements(stmtType) || v.Elem().Type().Implements(exprType) || v.Elem().Type().Implements(stmtType) {
+			return newDumpNode(v.Interface())
+		}
+	}
+
+	// Literal中的值
+	return v.Interface()
+}
+
+func (n *dumpNode) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, `{"kind":%q,"line":%d`, n.kind, n.line)
+	for _, field := range n.fields {
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buffer, ",%q:%s", field.name, value)
+	}
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+// WriteJSON 以JSON格式输出语法树，每个节点都有"kind"和"line"，其余的key是节点的字段名（首字母小写），
+// token输出为 {"type", "lexeme", "literal", "line", "column"}
+func WriteJSON(w io.Writer, program []Stmt) error {
+	nodes := make([]*dumpNode, len(program))
+	for i, stmt := range program {
+		nodes[i] = newDumpNode(stmt)
+	}
+
+	bs, err := json.Marshal(map[string]interface{}{"program": nodes})
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, bs, "", "  "); err != nil {
+		return err
+	}
+	out.WriteString("\n")
+
+	_, err = out.WriteTo(w)
+	return err
+}
+
+// WriteDot 以Graphviz的DOT格式输出语法树，可以用 dot -Tpng 直接渲染
+func WriteDot(w io.Writer, program []Stmt) error {
+	d := &dotWriter{w: bufio.NewWriter(w)}
+	d.printf("digraph AST {\n")
+	d.printf("  node [shape=box, fontname=\"Helvetica\"];\n")
+	d.printf("  edge [fontname=\"Helvetica\", fontsize=10];\n")
+
+	root := d.node("Program", nil)
+	for i, stmt := range program {
+		d.edge(root, d.tree(newDumpNode(stmt)), fmt.Sprintf("%d", i))
+	}
+
+	d.printf("}\n")
+
+	return d.w.Flush()
+}
+
+type dotWriter struct {
+	w     *bufio.Writer
+	count int
+}
+
+func (d *dotWriter) printf(format string, args ...interface{}) {
+	fmt.Fprintf(d.w, format, args...)
+}
+
+func (d *dotWriter) node(label string, lines []string) string {
+	id := fmt.Sprintf("n%d", d.count)
+	d.count++
+
+	label = strings.Join(append([]string{label}, lines...), "\n")
+	d.printf("  %s [label=%q];\n", id, label)
+
+	return id
+}
+
+func (d *dotWriter) edge(from, to, label string) {
+	d.printf("  %s -> %s [label=%q];\n", from, to, label)
+}
+
+// tree 输出一个节点，token和字面量写在节点的标签中，子节点用带有字段名的边连接
+func (d *dotWriter) tree(n *dumpNode) string {
+	var lines []string
+	type child struct {
+		label string
+		node  *dumpNode
+	}
+	var children []child
+
+	for _, field := range n.fields {
+		switch value := field.value.(type) {
+		case *dumpNode:
+			children = append(children, child{field.name, value})
+		case []interface{}:
+			var tokens []string
+			for i, element := range value {
+				switch element := element.(type) {
+				case *dumpNode:
+					children = append(children, child{fmt.Sprintf("%s[%d]", field.name, i), element})
+				case dumpToken:
+					tokens = append(tokens, element.Lexeme)
+				}
+			}
+			if len(tokens) > 0 {
+				lines = append(lines, field.name+": "+strings.Join(tokens, ", "))
+			}
+		case dumpToken:
+			lines = append(lines, field.name+": "+value.Lexeme)
+		case nil:
+		default:
+			lines = append(lines, fmt.Sprintf("%s: %#v", field.name, value))
+		}
+	}
+
+	id := d.node(fmt.Sprintf("%s (line %d)", n.kind, n.line), lines)
+	for _, c := range children {
+		d.edge(id, d.tree(c.node), c.label)
+	}
+
+	return id
+}
+
+func lowerFirst(s string) string {
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+
+	return string(r)
+}
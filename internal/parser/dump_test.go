@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"GLox/internal/scanner"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const dumpSource = `var a = 1 + 2;
+fun f(x) { return x or nil; }
+print f(a);`
+
+func parseSource(t *testing.T, source string) []Stmt {
+	t.Helper()
+
+	program, errs := NewParser(scanner.NewScanner(source).ScanTokens()).Parse()
+	if len(program) == 0 || len(errs) > 0 {
+		t.Fatalf("failed to parse %q: %v", source, errs)
+	}
+
+	return program
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := WriteJSON(&buffer, parseSource(t, dumpSource)); err != nil {
+		t.Fatal(err)
+	}
+
+	var tree struct {
+		Program []struct {
+			Kind string `json:"kind"`
+			Line int    `json:"line"`
+		} `json:"program"`
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &tree); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buffer.String())
+	}
+
+	expected := []string{"VarDeclStmt", "FuncDeclStmt", "PrintStmt"}
+	if len(tree.Program) != len(expected) {
+		t.Fatalf("expected %d statements, but got %d", len(expected), len(tree.Program))
+	}
+	for i, stmt := range tree.Program {
+		if stmt.Kind != expected[i] || stmt.Line != i+1 {
+			t.Errorf("statement %d: expected %s at line %d, but got %s at line %d", i, expected[i], i+1, stmt.Kind, stmt.Line)
+		}
+	}
+
+	for _, want := range []string{`"kind": "Binary"`, `"kind": "Logic"`, `"kind": "Call"`, `"lexeme": "+"`} {
+		if !strings.Contains(buffer.String(), want) {
+			t.Errorf("JSON is missing %s", want)
+		}
+	}
+}
+
+func TestWriteDot(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := WriteDot(&buffer, parseSource(t, dumpSource)); err != nil {
+		t.Fatal(err)
+	}
+
+	dot := buffer.String()
+	for _, want := range []string{"digraph AST {", `[label="Binary (line 1)\noperator: +"]`, `[label="initializer"]`, `[label="arguments[0]"]`} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output is missing %s:\n%s", want, dot)
+		}
+	}
+}
+
+func TestPrinter_PrintProgram(t *testing.T) {
+	expected := "(var a (+ 1 2))\n(fun f (x) (return (or x nil)))\n(print (call f a))"
+	if result := new(Printer).PrintProgram(parseSource(t, dumpSource)); result != expected {
+		t.Fatalf("expected %s, but got %s", expected, result)
+	}
+}
+
+// 脱糖之后 ++x 和 x += 1 的语义相同，输出时仍然要保留源代码中的运算符
+func TestPrinter_AssignOperators(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"++x;", "(; (++ x))"},
+		{"x += 1;", "(; (+= x 1))"},
+		{"a.b--;", "(; (postfix (-- (. a b))))"},
+		{"l[0] *= 2;", "(; (*= ([] l 0) 2))"},
+		{"a.b = 1;", "(; (= (. a b) 1))"},
+	}
+
+	for _, test := range tests {
+		if got := new(Printer).PrintProgram(parseSource(t, test.source)); got != test.want {
+			t.Errorf("%q: got %s, want %s", test.source, got, test.want)
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := WriteJSON(&buffer, parseSource(t, "++x;")); err != nil {
+		t.Fatal(err)
+	}
+	if want := `"lexeme": "++"`; !strings.Contains(buffer.String(), want) {
+		t.Errorf("JSON is missing %s:\n%s", want, buffer.String())
+	}
+}
@@ -1,3 +1,5 @@
+// Code generated by tools/ast from ast.schema; DO NOT EDIT.
+
 package parser
 
 import (
@@ -5,6 +7,7 @@ import (
 )
 
 type Expr interface {
+	Node
 	Accept(visitor ExprVisitor) (interface{}, error)
 }
 
@@ -15,7 +18,17 @@ type Binary struct {
 }
 
 func NewBinary(left Expr, operator *token.Token, right Expr) *Binary {
-	return &Binary{left, operator, right}
+	return &Binary{Left: left, Operator: operator, Right: right}
+}
+
+func (b *Binary) Pos() token.Position {
+	pos, _ := span(b)
+	return pos
+}
+
+func (b *Binary) End() token.Position {
+	_, end := span(b)
+	return end
 }
 
 func (b *Binary) Accept(visitor ExprVisitor) (interface{}, error) {
@@ -27,19 +40,37 @@ type Grouping struct {
 }
 
 func NewGrouping(expression Expr) *Grouping {
-	return &Grouping{expression}
+	return &Grouping{Expression: expression}
+}
+
+func (g *Grouping) Pos() token.Position {
+	pos, _ := span(g)
+	return pos
+}
+
+func (g *Grouping) End() token.Position {
+	_, end := span(g)
+	return end
 }
 
 func (g *Grouping) Accept(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitGroupingExpr(g)
 }
 
+// Literal 中Token是源码中对应的token，语法脱糖生成的字面量没有token
 type Literal struct {
 	Value interface{}
+	Token *token.Token
 }
 
-func NewLiteral(value interface{}) Expr {
-	return &Literal{value}
+func (l *Literal) Pos() token.Position {
+	pos, _ := span(l)
+	return pos
+}
+
+func (l *Literal) End() token.Position {
+	_, end := span(l)
+	return end
 }
 
 func (l *Literal) Accept(visitor ExprVisitor) (interface{}, error) {
@@ -52,7 +83,17 @@ type Unary struct {
 }
 
 func NewUnary(operator *token.Token, right Expr) *Unary {
-	return &Unary{operator, right}
+	return &Unary{Operator: operator, Right: right}
+}
+
+func (u *Unary) Pos() token.Position {
+	pos, _ := span(u)
+	return pos
+}
+
+func (u *Unary) End() token.Position {
+	_, end := span(u)
+	return end
 }
 
 func (u *Unary) Accept(visitor ExprVisitor) (interface{}, error) {
@@ -68,23 +109,48 @@ func NewVariable(name *token.Token) *Variable {
 	return &Variable{Name: name}
 }
 
+func (v *Variable) Pos() token.Position {
+	pos, _ := span(v)
+	return pos
+}
+
+func (v *Variable) End() token.Position {
+	_, end := span(v)
+	return end
+}
+
 func (v *Variable) Accept(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitVariableExpr(v)
 }
 
+// Assign 中的Equals是源代码中的赋值运算符（=、+=、++等），Operator是复合赋值对应的二元运算符，普通赋值时为nil。
+// ++x 和 x += 1 只有Equals不同，Set和IndexSet同理
 type Assign struct {
-	Name  *token.Token
-	Value Expr
+	Name     *token.Token
+	Equals   *token.Token
+	Operator *token.Token
+	Value    Expr
 }
 
-func NewAssign(name *token.Token, value Expr) *Assign {
-	return &Assign{Name: name, Value: value}
+func NewAssign(name *token.Token, equals *token.Token, operator *token.Token, value Expr) *Assign {
+	return &Assign{Name: name, Equals: equals, Operator: operator, Value: value}
+}
+
+func (a *Assign) Pos() token.Position {
+	pos, _ := span(a)
+	return pos
+}
+
+func (a *Assign) End() token.Position {
+	_, end := span(a)
+	return end
 }
 
 func (a *Assign) Accept(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitAssignExpr(a)
 }
 
+// Logic 中Operator是"and"、"or"或者"??"，右侧只在需要的时候计算
 type Logic struct {
 	Left     Expr
 	Operator *token.Token
@@ -95,31 +161,65 @@ func NewLogic(left Expr, operator *token.Token, right Expr) *Logic {
 	return &Logic{Left: left, Operator: operator, Right: right}
 }
 
+func (l *Logic) Pos() token.Position {
+	pos, _ := span(l)
+	return pos
+}
+
+func (l *Logic) End() token.Position {
+	_, end := span(l)
+	return end
+}
+
 func (l *Logic) Accept(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitLogicExpr(l)
 }
 
+// Call 中Optional为true表示 f?.() 形式的调用，Callee为nil时不调用，见OptionalChain
 type Call struct {
 	Callee    Expr
 	Paren     *token.Token
 	Arguments []Expr
+	Optional  bool
+}
+
+func NewCall(callee Expr, paren *token.Token, arguments []Expr, optional bool) *Call {
+	return &Call{Callee: callee, Paren: paren, Arguments: arguments, Optional: optional}
+}
+
+func (c *Call) Pos() token.Position {
+	pos, _ := span(c)
+	return pos
 }
 
-func NewCall(callee Expr, paren *token.Token, arguments []Expr) *Call {
-	return &Call{Callee: callee, Paren: paren, Arguments: arguments}
+func (c *Call) End() token.Position {
+	_, end := span(c)
+	return end
 }
 
 func (c *Call) Accept(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitCallExpr(c)
 }
 
+// Get 中Optional为true表示 obj?.attr 形式的访问
 type Get struct {
 	Object    Expr
 	Attribute *token.Token
+	Optional  bool
+}
+
+func NewGet(object Expr, attribute *token.Token, optional bool) *Get {
+	return &Get{Object: object, Attribute: attribute, Optional: optional}
+}
+
+func (g *Get) Pos() token.Position {
+	pos, _ := span(g)
+	return pos
 }
 
-func NewGet(object Expr, attribute *token.Token) *Get {
-	return &Get{Object: object, Attribute: attribute}
+func (g *Get) End() token.Position {
+	_, end := span(g)
+	return end
 }
 
 func (g *Get) Accept(visitor ExprVisitor) (interface{}, error) {
@@ -129,11 +229,23 @@ func (g *Get) Accept(visitor ExprVisitor) (interface{}, error) {
 type Set struct {
 	Object    Expr
 	Attribute *token.Token
+	Equals    *token.Token
+	Operator  *token.Token
 	Value     Expr
 }
 
-func NewSet(Object Expr, Attribute *token.Token, Value Expr) *Set {
-	return &Set{Object: Object, Attribute: Attribute, Value: Value}
+func NewSet(object Expr, attribute *token.Token, equals *token.Token, operator *token.Token, value Expr) *Set {
+	return &Set{Object: object, Attribute: attribute, Equals: equals, Operator: operator, Value: value}
+}
+
+func (s *Set) Pos() token.Position {
+	pos, _ := span(s)
+	return pos
+}
+
+func (s *Set) End() token.Position {
+	_, end := span(s)
+	return end
 }
 
 func (s *Set) Accept(visitor ExprVisitor) (interface{}, error) {
@@ -148,6 +260,16 @@ func NewThis(keyword *token.Token) *This {
 	return &This{Keyword: keyword}
 }
 
+func (t *This) Pos() token.Position {
+	pos, _ := span(t)
+	return pos
+}
+
+func (t *This) End() token.Position {
+	_, end := span(t)
+	return end
+}
+
 func (t *This) Accept(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitThisExpr(t)
 }
@@ -157,10 +279,241 @@ type Super struct {
 	Identifier *token.Token
 }
 
-func NewSuper(keyword, Identifier *token.Token) *Super {
-	return &Super{Keyword: keyword, Identifier: Identifier}
+func NewSuper(keyword *token.Token, identifier *token.Token) *Super {
+	return &Super{Keyword: keyword, Identifier: identifier}
+}
+
+func (s *Super) Pos() token.Position {
+	pos, _ := span(s)
+	return pos
+}
+
+func (s *Super) End() token.Position {
+	_, end := span(s)
+	return end
 }
 
 func (s *Super) Accept(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitSuperExpr(s)
 }
+
+// Index 下标访问表达式，如 foo[bar]
+type Index struct {
+	Object  Expr
+	Bracket *token.Token
+	Index   Expr
+}
+
+func NewIndex(object Expr, bracket *token.Token, index Expr) *Index {
+	return &Index{Object: object, Bracket: bracket, Index: index}
+}
+
+func (i *Index) Pos() token.Position {
+	pos, _ := span(i)
+	return pos
+}
+
+func (i *Index) End() token.Position {
+	_, end := span(i)
+	return end
+}
+
+func (i *Index) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitIndexExpr(i)
+}
+
+type IndexSet struct {
+	Object   Expr
+	Bracket  *token.Token
+	Index    Expr
+	Equals   *token.Token
+	Operator *token.Token
+	Value    Expr
+}
+
+func NewIndexSet(object Expr, bracket *token.Token, index Expr, equals *token.Token, operator *token.Token, value Expr) *IndexSet {
+	return &IndexSet{Object: object, Bracket: bracket, Index: index, Equals: equals, Operator: operator, Value: value}
+}
+
+func (i *IndexSet) Pos() token.Position {
+	pos, _ := span(i)
+	return pos
+}
+
+func (i *IndexSet) End() token.Position {
+	_, end := span(i)
+	return end
+}
+
+func (i *IndexSet) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitIndexSetExpr(i)
+}
+
+// Postfix 后缀自增/自减表达式（如 a++），Target是对应的复合赋值表达式，整个表达式的值是赋值前的旧值
+type Postfix struct {
+	Target Expr
+}
+
+func NewPostfix(target Expr) *Postfix {
+	return &Postfix{Target: target}
+}
+
+func (p *Postfix) Pos() token.Position {
+	pos, _ := span(p)
+	return pos
+}
+
+func (p *Postfix) End() token.Position {
+	_, end := span(p)
+	return end
+}
+
+func (p *Postfix) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitPostfixExpr(p)
+}
+
+// Lambda 匿名函数表达式，如 fun (a) { ... } 或 (a) => a * 2，Function的Name为nil
+type Lambda struct {
+	Keyword  *token.Token
+	Function *FuncDeclStmt
+}
+
+func NewLambda(keyword *token.Token, function *FuncDeclStmt) *Lambda {
+	return &Lambda{Keyword: keyword, Function: function}
+}
+
+func (l *Lambda) Pos() token.Position {
+	pos, _ := span(l)
+	return pos
+}
+
+func (l *Lambda) End() token.Position {
+	_, end := span(l)
+	return end
+}
+
+func (l *Lambda) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitLambdaExpr(l)
+}
+
+// List list字面量，如 [1, 2, 3]
+type List struct {
+	Bracket  *token.Token
+	Elements []Expr
+}
+
+func NewList(bracket *token.Token, elements []Expr) *List {
+	return &List{Bracket: bracket, Elements: elements}
+}
+
+func (l *List) Pos() token.Position {
+	pos, _ := span(l)
+	return pos
+}
+
+func (l *List) End() token.Position {
+	_, end := span(l)
+	return end
+}
+
+func (l *List) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitListExpr(l)
+}
+
+// Map map字面量，如 {"a": 1, "b": 2}，Keys和Values按顺序一一对应
+type Map struct {
+	Brace  *token.Token
+	Keys   []Expr
+	Values []Expr
+}
+
+func NewMap(brace *token.Token, keys []Expr, values []Expr) *Map {
+	return &Map{Brace: brace, Keys: keys, Values: values}
+}
+
+func (m *Map) Pos() token.Position {
+	pos, _ := span(m)
+	return pos
+}
+
+func (m *Map) End() token.Position {
+	_, end := span(m)
+	return end
+}
+
+func (m *Map) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitMapExpr(m)
+}
+
+// Conditional 条件表达式，如 cond ? a : b
+type Conditional struct {
+	Condition  Expr
+	Question   *token.Token
+	ThenBranch Expr
+	ElseBranch Expr
+}
+
+func NewConditional(condition Expr, question *token.Token, thenBranch Expr, elseBranch Expr) *Conditional {
+	return &Conditional{Condition: condition, Question: question, ThenBranch: thenBranch, ElseBranch: elseBranch}
+}
+
+func (c *Conditional) Pos() token.Position {
+	pos, _ := span(c)
+	return pos
+}
+
+func (c *Conditional) End() token.Position {
+	_, end := span(c)
+	return end
+}
+
+func (c *Conditional) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitConditionalExpr(c)
+}
+
+// OptionalChain 包住含有 ?. 的整个调用链，如 a?.b.c()，链中任意一个 ?. 左侧为nil时整个链的值都是nil
+type OptionalChain struct {
+	Expression Expr
+}
+
+func NewOptionalChain(expression Expr) *OptionalChain {
+	return &OptionalChain{Expression: expression}
+}
+
+func (o *OptionalChain) Pos() token.Position {
+	pos, _ := span(o)
+	return pos
+}
+
+func (o *OptionalChain) End() token.Position {
+	_, end := span(o)
+	return end
+}
+
+func (o *OptionalChain) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitOptionalChainExpr(o)
+}
+
+// BadExpr 容错解析时代替有语法错误的表达式，From和To是它覆盖的第一个和最后一个token
+type BadExpr struct {
+	From *token.Token
+	To   *token.Token
+}
+
+func NewBadExpr(from *token.Token, to *token.Token) *BadExpr {
+	return &BadExpr{From: from, To: to}
+}
+
+func (b *BadExpr) Pos() token.Position {
+	pos, _ := span(b)
+	return pos
+}
+
+func (b *BadExpr) End() token.Position {
+	_, end := span(b)
+	return end
+}
+
+func (b *BadExpr) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitBadExpr(b)
+}
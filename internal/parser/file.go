@@ -0,0 +1,274 @@
+package parser
+
+import (
+	"GLox/internal/loxerror"
+	"GLox/internal/numeric"
+	"GLox/internal/scanner"
+	"GLox/internal/scanner/token"
+	"sort"
+	"strings"
+)
+
+// File 是容错解析一个源文件的结果，给编辑器、formatter和linter这类需要处理未写完的代码的工具使用。
+// 和Parse不同，出错的declaration不会被丢弃，而是用BadStmt和BadExpr代替无法解析的部分，
+// 所以语法树总是覆盖整个文件。Edit之后只重新解析受影响的顶层declaration
+type File struct {
+	Source string
+	Decls  []*Decl
+	EOF    *token.Token
+
+	trailing loxerror.ParseErrors // 最后一个declaration之后的词法错误（比如没有结束的字符串）
+	pragmas  []scanner.Pragma     // 源码中所有的 "//glox:numbers=" 注释，Edit用它确定重新解析的部分的数字模式
+}
+
+// Decl 是一个顶层的declaration，Tokens是它包含的所有token（包括被跳过的），Errors是解析它时遇到的错误
+type Decl struct {
+	Stmt   Stmt
+	Tokens []*token.Token
+	Errors loxerror.ParseErrors
+
+	lookahead bool // 解析时看过了它之后的第一个token（比如if后面是否有else），这个token改变时需要重新解析它
+	// lookbehind 表示它的某个错误和前一个declaration的最后一个错误位置相同，所以没有记录。
+	// 前一个declaration改变时需要重新解析它
+	lookbehind bool
+}
+
+// Start 返回declaration第一个token在源码中的位置
+func (d *Decl) Start() int { return d.Tokens[0].Offset }
+
+// End 返回declaration最后一个token之后的位置
+func (d *Decl) End() int { return d.Tokens[len(d.Tokens)-1].EndOffset() }
+
+// ParseFile 容错地解析整个源文件
+func ParseFile(source string) *File {
+	f := &File{Source: source}
+	r := parseDecls(source, 0, len(source), 1, numeric.Float)
+	f.Decls, f.EOF, f.trailing, f.pragmas = r.decls, r.eof, r.trailing, r.pragmas
+
+	return f
+}
+
+// parsed 是parseDecls的结果，trailing是最后一个declaration之后的词法错误，pragmas是范围中的pragma
+type parsed struct {
+	decls    []*Decl
+	eof      *token.Token
+	trailing loxerror.ParseErrors
+	pragmas  []scanner.Pragma
+}
+
+// parseDecls 容错地解析从start到end之间的所有顶层declaration，line是start所在的行，mode是start处生效的数字模式。
+// 词法错误属于它所在的或者它之后的第一个declaration，和语法错误一起按位置排列
+func parseDecls(source string, start, end, line int, mode numeric.Mode) parsed {
+	s := scanner.NewRangeScanner(source, start, end, line)
+	s.SetNumberMode(mode)
+	s.CollectErrors()
+	p := NewParser(s.ScanTokens())
+	p.tolerant = true
+
+	var decls []*Decl
+	for !p.isAtEnd() {
+		first := p.current
+		p.firstError, p.lookbehind = len(p.errors), false
+		stmt, err := p.declaration()
+		if err != nil {
+			stmt = p.recoverStmt(err, first)
+		}
+
+		decls = append(decls, &Decl{
+			Stmt:       stmt,
+			Tokens:     p.tokens[first:p.current],
+			Errors:     p.errors[p.firstError:],
+			lookahead:  p.lookahead >= p.current,
+			lookbehind: p.lookbehind,
+		})
+	}
+
+	lexErrors := s.Errors()
+	for _, d := range decls {
+		var errors loxerror.ParseErrors
+		for len(lexErrors) > 0 && lexErrors[0].Token().Offset < d.End() {
+			errors, lexErrors = append(errors, lexErrors[0]), lexErrors[1:]
+		}
+		if len(errors) > 0 {
+			d.Errors = append(errors, d.Errors...)
+			sortErrors(d.Errors)
+		}
+	}
+
+	return parsed{decls: decls, eof: p.tokens[len(p.tokens)-1], trailing: lexErrors, pragmas: s.Pragmas()}
+}
+
+// sortErrors 按位置排列错误，位置相同的保持原来的顺序
+func sortErrors(errors loxerror.ParseErrors) {
+	sort.SliceStable(errors, func(i, j int) bool {
+		return errors[i].Token().Offset < errors[j].Token().Offset
+	})
+}
+
+// numberMode 返回offset处生效的数字模式，也就是offset之前最后一个pragma设置的模式
+func numberMode(pragmas []scanner.Pragma, offset int, mode numeric.Mode) numeric.Mode {
+	for _, pragma := range pragmas {
+		if pragma.Offset >= offset {
+			break
+		}
+		mode = pragma.Mode
+	}
+
+	return mode
+}
+
+// Stmts 返回所有顶层的statement，可以直接交给resolver或者printer
+func (f *File) Stmts() []Stmt {
+	stmts := make([]Stmt, len(f.Decls))
+	for i, d := range f.Decls {
+		stmts[i] = d.Stmt
+	}
+
+	return stmts
+}
+
+// Errors 按出现的顺序返回所有declaration中的错误
+func (f *File) Errors() loxerror.ParseErrors {
+	var errors loxerror.ParseErrors
+	for _, d := range f.Decls {
+		errors = append(errors, d.Errors...)
+	}
+
+	errors = append(errors, f.trailing...)
+	// 最后一个declaration缺少的部分在EOF处报错，可能在它之后的词法错误的后面
+	sortErrors(errors)
+
+	return errors
+}
+
+// DeclAt 返回包含offset的顶层declaration，offset在declaration之间的时候返回nil
+func (f *File) DeclAt(offset int) *Decl {
+	for _, d := range f.Decls {
+		if d.Start() <= offset && offset < d.End() {
+			return d
+		}
+	}
+
+	return nil
+}
+
+// Edit 把源码中[start, end)的字节替换成text，然后重新解析和修改范围重叠或者相邻的顶层declaration，
+// 以及它们之间的空白和注释。其他declaration的语法树保持不变，修改之后的token只平移位置。
+// 修改影响到后面的代码时（比如删除了一个 '}'，或者加上了没有结束的字符串），退回到重新解析整个文件
+func (f *File) Edit(start, end int, text string) {
+	source := f.Source[:start] + text + f.Source[end:]
+	delta := len(text) - (end - start)
+
+	// Decls[first:last] 是需要重新解析的declaration
+	first, last := 0, len(f.Decls)
+	for first < last && f.Decls[first].End() < start {
+		first++
+	}
+	for last > first && f.Decls[last-1].Start() > end {
+		last--
+	}
+	// 前一个declaration变了，错误依赖于它的declaration也要重新解析
+	for last < len(f.Decls) && f.Decls[last].lookbehind {
+		last++
+	}
+	// 修改的declaration之后的token变了，看过这个token的前一个declaration也要重新解析
+	for first > 0 && f.Decls[first-1].lookahead {
+		first--
+	}
+
+	from, to, line := 0, len(source), 1
+	if first > 0 {
+		prev := f.Decls[first-1].Tokens
+		from, line = f.Decls[first-1].End(), prev[len(prev)-1].End().Line
+	}
+	if last < len(f.Decls) {
+		to = f.Decls[last].Start() + delta
+	}
+
+	// 重新解析的部分从from处生效的数字模式开始，结束时的模式变了（比如增加或者删除了pragma），
+	// 后面的declaration中的数字字面量也要改变
+	mode := numberMode(f.pragmas, from, numeric.Float)
+	r := parseDecls(source, from, to, line, mode)
+	if last < len(f.Decls) && (!closed(r.decls, r.eof, to) || numberMode(r.pragmas, to, mode) != numberMode(f.pragmas, to-delta, numeric.Float) ||
+		len(r.trailing) > 0 || f.Decls[last].gapErrors()) {
+		// 重新扫描的范围最后的词法错误属于Decls[last]，简单起见也重新解析整个文件
+		*f = *ParseFile(source)
+		return
+	}
+
+	// 平移之后的token，和修改的结尾在同一行的token还要重新计算列号。
+	// 语法树中脱糖生成的token（比如 "+=" 中的 '+'）不在Tokens中，也要平移
+	lineDelta := strings.Count(text, "\n") - strings.Count(f.Source[start:end], "\n")
+	endLine := strings.Count(f.Source[:end], "\n") + 1
+	lineStart := strings.LastIndexByte(source[:start+len(text)], '\n') + 1
+	shifted := make(map[*token.Token]bool)
+	shift := func(t *token.Token) {
+		// 脱糖生成的没有位置的token保持不变
+		if shifted[t] || !t.Pos().IsValid() {
+			return
+		}
+		shifted[t] = true
+		if t.Line == endLine {
+			t.Column = t.Offset + delta - lineStart + 1
+		}
+		t.Offset += delta
+		t.Line += lineDelta
+	}
+	for _, d := range f.Decls[last:] {
+		for _, t := range d.Tokens {
+			shift(t)
+		}
+		// 词法错误的token不在Tokens中
+		for _, err := range d.Errors {
+			shift(err.Token())
+		}
+		Walk(d.Stmt, func(n Node) bool {
+			if n != nil {
+				walkTokens(n, shift)
+			}
+			return true
+		})
+	}
+	if last < len(f.Decls) {
+		shift(f.EOF)
+		for _, err := range f.trailing {
+			shift(err.Token())
+		}
+	} else {
+		f.EOF, f.trailing = r.eof, r.trailing
+	}
+
+	// 重新解析的范围中的pragma替换原来的，之后的平移位置
+	var updated []scanner.Pragma
+	for _, pragma := range f.pragmas {
+		if pragma.Offset < from {
+			updated = append(updated, pragma)
+		}
+	}
+	updated = append(updated, r.pragmas...)
+	for _, pragma := range f.pragmas {
+		if pragma.Offset >= to-delta {
+			updated = append(updated, scanner.Pragma{Offset: pragma.Offset + delta, Mode: pragma.Mode})
+		}
+	}
+	f.pragmas = updated
+
+	f.Source = source
+	f.Decls = append(append(append([]*Decl{}, f.Decls[:first]...), r.decls...), f.Decls[last:]...)
+}
+
+// gapErrors 判断declaration是否有在它第一个token之前的词法错误
+func (d *Decl) gapErrors() bool {
+	return len(d.Errors) > 0 && d.Errors[0].Token().Offset < d.Start()
+}
+
+// closed 判断重新解析的部分是否在to处完整地结束：没有跨过to的token或者注释，
+// 最后一个declaration也没有看过结尾的EOF（比如缺少 '}' 时跳过的block一直到了结尾），
+// 否则和后面的declaration一起解析的结果可能不同
+func closed(decls []*Decl, eof *token.Token, to int) bool {
+	if eof.Offset != to {
+		return false
+	}
+
+	return len(decls) == 0 || !decls[len(decls)-1].lookahead
+}
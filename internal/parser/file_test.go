@@ -0,0 +1,264 @@
+package parser
+
+import (
+	"GLox/internal/loxerror"
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestParseFileTolerant(t *testing.T) {
+	source := `var a = ;
+fun f(x) {
+  print x +;
+  return x;
+}
+class A { foo() { retrun 1; } }
+print a;`
+
+	f := ParseFile(source)
+	if len(f.Decls) != 4 {
+		t.Fatalf("got %d decls, want 4: %v", len(f.Decls), f.Errors())
+	}
+
+	// var a = ; 的初始值是一个BadExpr，位置在 ';'
+	bad, ok := f.Decls[0].Stmt.(*VarDeclStmt).Initializer.(*BadExpr)
+	if !ok || bad.Pos().String() != "1:9" || bad.End().String() != "1:10" {
+		t.Errorf("got initializer %#v, want BadExpr at 1:9", f.Decls[0].Stmt.(*VarDeclStmt).Initializer)
+	}
+
+	// 函数体中出错的statement变成BadStmt，后面的return保留下来
+	body := f.Decls[1].Stmt.(*FuncDeclStmt).Body.Stmts
+	if len(body) != 2 {
+		t.Fatalf("got %d stmts in function body, want 2", len(body))
+	}
+	if _, ok := body[1].(*ReturnStmt); !ok {
+		t.Errorf("got %T, want *ReturnStmt", body[1])
+	}
+
+	method := f.Decls[2].Stmt.(*ClassDeclStmt).Methods[0]
+	if stmt, ok := method.Body.Stmts[0].(*BadStmt); !ok || stmt.Pos().String() != "6:19" || stmt.End().String() != "6:28" {
+		t.Errorf("got %#v, want BadStmt from 6:19 to 6:28", method.Body.Stmts[0])
+	}
+
+	var errors []string
+	for _, err := range f.Errors() {
+		errors = append(errors, err.Error())
+	}
+	want := []string{
+		"[parse error] line 1:9: Expect expression, found ';'.",
+		"[parse error] line 3:12: Expect expression, found ';'.",
+		"[parse error] line 6:26: Expect ';' after value, found '1'. Did you mean 'return'?",
+	}
+	if strings.Join(errors, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got errors:\n%s\nwant:\n%s", strings.Join(errors, "\n"), strings.Join(want, "\n"))
+	}
+
+	if d := f.DeclAt(strings.Index(source, "retrun")); d != f.Decls[2] {
+		t.Errorf("DeclAt returned %v, want the class", d)
+	}
+}
+
+// 词法错误和语法错误一起出现在Errors中，不会通过log报告，也不会设置全局的HadError
+func TestParseFileLexErrors(t *testing.T) {
+	defer func(hadError bool) { loxerror.HadError = hadError }(loxerror.HadError)
+	loxerror.HadError = false
+
+	tests := []struct {
+		source string
+		want   []string
+	}{
+		{"print \"abc;", []string{
+			"[parse error] line 1:7: Unterminated string.",
+			"[parse error] line 1:12: Expect expression, found end of file.",
+		}},
+		{"var a = 0x;\nprint @ a;", []string{
+			"[parse error] line 1:9: Invalid number literal 0x.",
+			"[parse error] line 1:11: Expect expression, found ';'.",
+			"[parse error] line 2:7: Unexpected character @",
+		}},
+	}
+
+	for _, test := range tests {
+		var errors []string
+		for _, err := range ParseFile(test.source).Errors() {
+			errors = append(errors, err.Error())
+		}
+		if strings.Join(errors, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("%q: got errors:\n%s\nwant:\n%s", test.source, strings.Join(errors, "\n"), strings.Join(test.want, "\n"))
+		}
+	}
+	if loxerror.HadError {
+		t.Errorf("ParseFile set loxerror.HadError")
+	}
+}
+
+func TestFileEdit(t *testing.T) {
+	const source = `var a = 1;
+fun f(x) { return x + a; }
+
+// comment
+class A { foo() { a += 1; } }
+print f(2); print a;`
+
+	tests := []struct {
+		name     string
+		old, new string
+		reused   int // 没有重新解析的declaration个数
+	}{
+		{"change a number", "x + a", "x + a * 2", 4},
+		{"insert a line", "// comment\n", "// comment\nvar b = 2;\n", 4},
+		{"half typed", "print a;", "print a", 4},
+		{"delete a declaration", "var a = 1;\n", "", 3},
+		{"edit between declarations", "comment", "a longer comment", 5},
+		{"unclosed brace", "{ return x + a; }", "{ return x + a;", 0},
+		{"unclosed call", "f(2);", "f(2", 0},
+		{"comment out the following code", "f(2);", "f(2); //", 0},
+		{"unterminated string", "var a = 1;", `var a = "1;`, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := ParseFile(source)
+			decls := make(map[*Decl]bool)
+			for _, d := range f.Decls {
+				decls[d] = true
+			}
+
+			start := strings.Index(source, test.old)
+			f.Edit(start, start+len(test.old), test.new)
+
+			want := ParseFile(strings.Replace(source, test.old, test.new, 1))
+			if f.Source != want.Source {
+				t.Fatalf("got source %q, want %q", f.Source, want.Source)
+			}
+			if got, want := dumpFile(t, f), dumpFile(t, want); got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+
+			reused := 0
+			for _, d := range f.Decls {
+				if decls[d] {
+					reused++
+				}
+			}
+			if reused != test.reused {
+				t.Errorf("reused %d declarations, want %d", reused, test.reused)
+			}
+		})
+	}
+}
+
+// 前一个declaration已经在 '}' 处报告了错误，所以 '}' 组成的BadStmt没有记录自己的错误。
+// 前一个declaration改变之后，'}' 的错误要重新出现
+func TestFileEditSuppressedError(t *testing.T) {
+	f := ParseFile("fun f(x) {\n  return x + 1;\n}\nprint 1;\n")
+	f.Edit(9, 13, "//")
+	f.Edit(8, 11, "yield ")
+
+	if got, want := dumpFile(t, f), dumpFile(t, ParseFile(f.Source)); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// pragma在重新解析的部分之前或者之中的时候，数字字面量的类型要和重新解析整个文件相同
+func TestFileEditNumberMode(t *testing.T) {
+	const source = `//glox:numbers=exact
+var a = 1;
+var b = 2;
+//glox:numbers=float
+var c = 3;`
+
+	tests := []struct {
+		name     string
+		old, new string
+	}{
+		{"after the pragma", "2;", "2 + 1;"},
+		{"after the second pragma", "3;", "3 + 1;"},
+		{"remove the pragma", "//glox:numbers=exact\n", ""},
+		{"change the pragma", "numbers=float", "numbers=exact"},
+		{"comment out the pragma", "//glox", "// glox"},
+		{"add a pragma", "var b", "//glox:numbers=float\nvar b"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := ParseFile(source)
+			start := strings.Index(source, test.old)
+			f.Edit(start, start+len(test.old), test.new)
+
+			if got, want := dumpFile(t, f), dumpFile(t, ParseFile(f.Source)); got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+// dumpFile 输出语法树、每个token的位置以及所有的错误，用来比较两个File是否相同
+func dumpFile(t *testing.T, f *File) string {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	if err := WriteJSON(&buffer, f.Stmts()); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range f.Decls {
+		for _, tok := range d.Tokens {
+			fmt.Fprintf(&buffer, "%s@%d %s\n", tok.Lexeme, tok.Offset, tok.Pos())
+		}
+	}
+	fmt.Fprintf(&buffer, "EOF@%d %s\n%v\n", f.EOF.Offset, f.EOF.Pos(), f.Errors())
+	// JSON中的1和big.Int的1是一样的，数字字面量的类型要单独比较
+	WalkProgram(f.Stmts(), func(n Node) bool {
+		if literal, ok := n.(*Literal); ok {
+			fmt.Fprintf(&buffer, "%T ", literal.Value)
+		}
+		return true
+	})
+
+	return buffer.String()
+}
+
+// TestFileEditRandom 对同一个文件做随机的修改，每次Edit之后的结果都要和重新解析整个文件相同
+func TestFileEditRandom(t *testing.T) {
+	const source = `var a = 1;
+fun f(x) { return x + a; }
+
+// comment
+class A < B with T { foo() { a += 1; } get bar { return "s"; } }
+print f(a);
+{ var b = [1, 2]; for (x in b) print x; }
+if (a) print a;
+while (a < 3) a++;
+a = 2;`
+	fragments := []string{"{", "}", "(", ")", ";", "\"", "//", "\n", "var ", "fun ", "class ", "print ", "return ",
+		"else ", "if (a) ", "x", "a = ", "+", ",", "[", "]", "1", "f(", "=>", ".", "retrun ", "//glox:numbers=exact\n"}
+
+	sequences := 3000
+	if testing.Short() {
+		sequences = 300
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for n := 0; n < sequences; n++ {
+		f := ParseFile(source)
+		for step := 0; step < 3; step++ {
+			start := rnd.Intn(len(f.Source) + 1)
+			end := start + rnd.Intn(4)
+			if end > len(f.Source) {
+				end = len(f.Source)
+			}
+			var text string
+			for k := rnd.Intn(3); k > 0; k-- {
+				text += fragments[rnd.Intn(len(fragments))]
+			}
+
+			old := f.Source
+			f.Edit(start, end, text)
+			if got, want := dumpFile(t, f), dumpFile(t, ParseFile(f.Source)); got != want {
+				t.Fatalf("Edit(%d, %d, %q) of\n%s\ngot:\n%s\nwant:\n%s", start, end, text, old, got, want)
+			}
+		}
+	}
+}
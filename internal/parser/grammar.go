@@ -1,40 +1,40 @@
 package parser
 
 import (
-	"GLox/internal/loxerror"
 	"GLox/internal/scanner/token"
 	"GLox/utils"
 )
 
-// declaration -> varDecl | funcDecl | classDecl | statement
+// declaration -> varDecl | funcDecl | classDecl | traitDecl | statement
 func (p *Parser) declaration() (Stmt, error) {
-	//defer func() {
-	//	if err := recover(); err != nil {
-	//		// 当解释器出现错误的时候，进行同步，让解释器跳转到下一个语句或者声明的开头
-	//		p.synchronize()
-	//	}
-	//}()
+	p.start = p.current
 	// 如果可以匹配到 var 关键字，则为varDecl
 	if p.match(token.VAR) {
 		return p.varDecl()
 	}
 
 	// funcDecl -> "fun" function
-	// 同 varDecl, 也可以看做是 statement 的一部分
-	if p.match(token.FUN) {
-		return p.functionDecl("function")
+	// 同 varDecl, 也可以看做是 statement 的一部分，"fun" 后面没有函数名的是匿名函数表达式
+	if p.check(token.FUN) && p.checkNext(token.IDENTIFIER) {
+		p.advance()
+		return p.functionDecl(p.previous(), "function")
 	}
 
 	if p.match(token.CLASS) {
 		return p.classDecl()
 	}
 
+	if p.match(token.TRAIT) {
+		return p.traitDecl()
+	}
+
 	return p.statement()
 }
 
 // varDecl -> "var" IDENTIFIER ( "=" expression )? ";"
 // varDecl 本身也可以看作是statement的一部分
 func (p *Parser) varDecl() (Stmt, error) {
+	keyword := p.previous()
 	name, err := p.consume(token.IDENTIFIER, "Expect variable name.")
 	if err != nil {
 		return nil, err
@@ -51,29 +51,63 @@ func (p *Parser) varDecl() (Stmt, error) {
 	// 注意最后consume掉一个分号
 	_, err = p.consume(token.SEMICOLON, "Expect ';' after variable declaration.")
 
-	return NewVarDeclStmt(name, initializer), err
+	return NewVarDeclStmt(keyword, name, initializer), err
 }
 
 // functionDecl -> IDENTIFIER "(" parameters? ")" block
 // 将 functionDecl 单独抽离出来，可以在定义方法的时候复用这一条规则
+// @param keyword: 函数名之前的"fun"、"class"或者"set"，普通的方法为nil
 // @param kind: "functionDecl" or "method"
-func (p *Parser) functionDecl(kind string) (Stmt, error) {
+func (p *Parser) functionDecl(keyword *token.Token, kind string) (Stmt, error) {
 	// 获取函数/方法名
 	name, err := p.consume(token.IDENTIFIER, "Expect "+kind+" name.")
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = p.consume(token.LEFT_PAREN, "Expect '(' after "+kind+" name.")
+	parameters, body, err := p.functionBody(kind)
 	if err != nil {
 		return nil, err
 	}
 
+	return NewFuncDeclStmt(keyword, name, parameters, body), nil
+}
+
+// functionBody -> "(" parameters? ")" block
+// 函数声明和匿名函数共用参数列表和函数体的文法
+func (p *Parser) functionBody(kind string) ([]*token.Token, *BlockStmt, error) {
+	_, err := p.consume(token.LEFT_PAREN, "Expect '(' after "+kind+" name.")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parameters, err := p.parameters()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// consume掉 "{"，一个函数体（block）的开始
+	_, err = p.consume(token.LEFT_BRACE, "Expect '{' before "+kind+" body.")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stmts, err := p.block()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return parameters, NewBlockStmt(stmts), nil
+}
+
+// parameters -> IDENTIFIER ( "," IDENTIFIER )* ")"
+// 调用前 "(" 已经被consume掉了
+func (p *Parser) parameters() ([]*token.Token, error) {
 	var parameters []*token.Token
 	if !p.check(token.RIGHT_PAREN) {
 		for {
-			if len(parameters) >= 255 {
-				panic(loxerror.NewParseError(p.peek(), "Can't have more than 255 parameters."))
+			if len(parameters) == 255 {
+				p.report(p.error(p.peek(), "Can't have more than 255 parameters."))
 			}
 			// 获取参数名，Lox是动态类型，没有类型声明
 			para, err := p.consume(token.IDENTIFIER, "Expect parameter name.")
@@ -88,29 +122,15 @@ func (p *Parser) functionDecl(kind string) (Stmt, error) {
 		}
 	}
 	// consume掉 ")"
-	_, err = p.consume(token.RIGHT_PAREN, "Expect ')' after parameters.")
-	if err != nil {
-		return nil, err
-	}
-
-	// consume掉 "{"，一个函数体（block）的开始
-	_, err = p.consume(token.LEFT_BRACE, "Expect '{' before "+kind+" body.")
-	if err != nil {
-		return nil, err
-	}
-
-	stmts, err := p.block()
-	if err != nil {
-		return nil, err
-	}
+	_, err := p.consume(token.RIGHT_PAREN, "Expect ')' after parameters.")
 
-	body := NewBlockStmt(stmts)
-
-	return NewFunctionStmt(name, parameters, body), nil
+	return parameters, err
 }
 
-// classDecl -> "class" IDENTIFIER ( "<" IDENTIFIER )? "{" function* "}" ;
+// classDecl -> "class" IDENTIFIER ( "<" IDENTIFIER )? ( "with" IDENTIFIER ( "," IDENTIFIER )* )? "{" member* "}" ;
+// member    -> "class" function | "var" IDENTIFIER ( "=" expression )? ";" | "set" function | IDENTIFIER block | function
 func (p *Parser) classDecl() (Stmt, error) {
+	keyword := p.previous()
 	name, err := p.consume(token.IDENTIFIER, "Expect class name.")
 	if err != nil {
 		return nil, err
@@ -126,27 +146,135 @@ func (p *Parser) classDecl() (Stmt, error) {
 		superclass = NewVariable(identifier)
 	}
 
+	// "with"和"set"、"in"一样不是关键字，类名（或者父类名）后面的IDENTIFIER只能是它
+	var traits []*Variable
+	if p.check(token.IDENTIFIER) && p.peek().Lexeme == "with" {
+		p.advance()
+		for {
+			identifier, err := p.consume(token.IDENTIFIER, "Expect trait name after 'with'.")
+			if err != nil {
+				return nil, err
+			}
+
+			traits = append(traits, NewVariable(identifier))
+			if !p.match(token.COMMA) {
+				break
+			}
+		}
+	}
+
 	_, err = p.consume(token.LEFT_BRACE, "Expect '{' before class body.")
 	if err != nil {
 		return nil, err
 	}
 
+	// 一个成员有错误的时候跳过这个成员，继续解析后面的成员
+	members := &classMembers{}
+	for !p.check(token.RIGHT_BRACE) && !p.isAtEnd() {
+		start := p.current
+		if err := p.member(members); err != nil {
+			p.recoverMember(err, start)
+		}
+	}
+
+	_, err = p.consume(token.RIGHT_BRACE, "Expect '}' after class body.")
+
+	return NewClassDeclStmt(keyword, name, superclass, traits, members.methods, members.classMethods, members.getters, members.setters, members.fields), err
+}
+
+type classMembers struct {
+	methods, classMethods, getters, setters []*FuncDeclStmt
+	fields                                  []*VarDeclStmt
+}
+
+// member 解析类中的一个成员，添加到members中
+func (p *Parser) member(members *classMembers) error {
+	// 成员名不会是拼错的关键字
+	p.start = -1
+
+	switch {
+	case p.match(token.CLASS):
+		// 静态方法，直接通过类调用
+		method, err := p.functionDecl(p.previous(), "class method")
+		if err != nil {
+			return err
+		}
+
+		members.classMethods = append(members.classMethods, method.(*FuncDeclStmt))
+	case p.match(token.VAR):
+		// 类级别的常量
+		field, err := p.varDecl()
+		if err != nil {
+			return err
+		}
+
+		members.fields = append(members.fields, field.(*VarDeclStmt))
+	case p.check(token.IDENTIFIER) && p.peek().Lexeme == "set" && p.checkNext(token.IDENTIFIER):
+		// "set"不是关键字，只有后面紧跟方法名的时候才表示setter
+		setter, err := p.functionDecl(p.advance(), "setter")
+		if err != nil {
+			return err
+		}
+
+		if len(setter.(*FuncDeclStmt).Params) != 1 {
+			p.report(p.error(setter.(*FuncDeclStmt).Name, "Setter must take exactly one parameter."))
+		}
+
+		members.setters = append(members.setters, setter.(*FuncDeclStmt))
+	case p.check(token.IDENTIFIER) && p.checkNext(token.LEFT_BRACE):
+		// getter没有参数列表，访问属性的时候直接执行
+		getter := p.advance()
+		p.advance()
+		stmts, err := p.block()
+		if err != nil {
+			return err
+		}
+
+		members.getters = append(members.getters, NewFuncDeclStmt(nil, getter, nil, NewBlockStmt(stmts)))
+	default:
+		method, err := p.functionDecl(nil, "method")
+		if err != nil {
+			return err
+		}
+
+		members.methods = append(members.methods, method.(*FuncDeclStmt))
+	}
+
+	return nil
+}
+
+// traitDecl -> "trait" IDENTIFIER "{" function* "}" ;
+func (p *Parser) traitDecl() (Stmt, error) {
+	keyword := p.previous()
+	name, err := p.consume(token.IDENTIFIER, "Expect trait name.")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.LEFT_BRACE, "Expect '{' before trait body.")
+	if err != nil {
+		return nil, err
+	}
+
 	var methods []*FuncDeclStmt
 	for !p.check(token.RIGHT_BRACE) && !p.isAtEnd() {
-		method, err := p.functionDecl("method")
+		start := p.current
+		p.start = -1
+		method, err := p.functionDecl(nil, "method")
 		if err != nil {
-			return nil, err
+			p.recoverMember(err, start)
+			continue
 		}
 
 		methods = append(methods, method.(*FuncDeclStmt))
 	}
 
-	_, err = p.consume(token.RIGHT_BRACE, "Expected '}' after class body.")
+	_, err = p.consume(token.RIGHT_BRACE, "Expect '}' after trait body.")
 
-	return NewClassDeclStmt(name, superclass, methods), err
+	return NewTraitDeclStmt(keyword, name, methods), err
 }
 
-// statement -> exprStmt | printStmt | block | ifStmt | whileStmt | forStmt ｜ returnStmt
+// statement -> exprStmt | printStmt | block | ifStmt | whileStmt | forStmt | forInStmt ｜ returnStmt | yieldStmt
 func (p *Parser) statement() (Stmt, error) {
 	if p.match(token.PRINT) {
 		return p.printStmt()
@@ -174,6 +302,10 @@ func (p *Parser) statement() (Stmt, error) {
 		return p.returnStmt()
 	}
 
+	if p.match(token.YIELD) {
+		return p.yieldStmt()
+	}
+
 	return p.exprStmt()
 }
 
@@ -194,6 +326,7 @@ func (p *Parser) exprStmt() (Stmt, error) {
 // printStmt -> "print" expression ";"
 func (p *Parser) printStmt() (Stmt, error) {
 	// "print"已经在statement()中consume掉了（用于区分stmt的类型），所以这里不需要再match一遍
+	keyword := p.previous()
 	value, err := p.expression()
 	if err != nil {
 		return nil, err
@@ -202,18 +335,21 @@ func (p *Parser) printStmt() (Stmt, error) {
 	// consume ';'
 	_, err = p.consume(token.SEMICOLON, "Expect ';' after value.")
 
-	return NewPrintStmt(value), err
+	return NewPrintStmt(keyword, value), err
 }
 
 // block -> "{" + declaration* + "}"
 func (p *Parser) block() (stmts []Stmt, err error) {
 	for !p.check(token.RIGHT_BRACE) && !p.isAtEnd() {
+		// block中的错误在block内部恢复，不会影响外层的函数或者类
+		start := p.current
 		stmt, err := p.declaration()
 		if err != nil {
-			return nil, err
+			stmt = p.recoverStmt(err, start)
+		}
+		if stmt != nil {
+			stmts = append(stmts, stmt)
 		}
-
-		stmts = append(stmts, stmt)
 	}
 	_, err = p.consume(token.RIGHT_BRACE, "Expect '}' after block.")
 
@@ -222,6 +358,7 @@ func (p *Parser) block() (stmts []Stmt, err error) {
 
 // ifStmt -> "if" "(" expression ")" statement ( "else" statement )?
 func (p *Parser) ifStmt() (Stmt, error) {
+	keyword := p.previous()
 	_, err := p.consume(token.LEFT_PAREN, "Expect '(' after 'if'.")
 	if err != nil {
 		return nil, err
@@ -250,11 +387,12 @@ func (p *Parser) ifStmt() (Stmt, error) {
 		}
 	}
 
-	return NewIfStmt(condition, thenBranch, elseBranch), nil
+	return NewIfStmt(keyword, condition, thenBranch, elseBranch), nil
 }
 
 // whileStmt -> "while" "(" expression ")" statement
 func (p *Parser) whileStmt() (Stmt, error) {
+	keyword := p.previous()
 	_, err := p.consume(token.LEFT_PAREN, "Expect '(' after 'while'.")
 	if err != nil {
 		return nil, err
@@ -272,16 +410,21 @@ func (p *Parser) whileStmt() (Stmt, error) {
 
 	body, err := p.statement()
 
-	return NewWhileStmt(condition, body), err
+	return NewWhileStmt(keyword, condition, body), err
 }
 
-// forStmt 由语法糖实现
+// forStmt 由语法糖实现，"(" 后面是 "var"? IDENTIFIER "in" 的时候是forInStmt
 func (p *Parser) forStmt() (Stmt, error) {
+	keyword := p.previous()
 	_, err := p.consume(token.LEFT_PAREN, "Expect '(' after 'for'.")
 	if err != nil {
 		return nil, err
 	}
 
+	if p.isForIn() {
+		return p.forInStmt(keyword)
+	}
+
 	var initializer Stmt
 	// for循环的初始化部分
 	if p.match(token.SEMICOLON) {
@@ -340,7 +483,7 @@ func (p *Parser) forStmt() (Stmt, error) {
 	}
 
 	condition = utils.Ternary(condition == nil, NewLiteral(true), condition)
-	body = NewWhileStmt(condition, body)
+	body = NewWhileStmt(keyword, condition, body)
 
 	if initializer != nil {
 		body = NewBlockStmt([]Stmt{initializer, body})
@@ -349,6 +492,42 @@ func (p *Parser) forStmt() (Stmt, error) {
 	return body, nil
 }
 
+// isForIn 向前看，判断 "(" 之后是否为for-in循环的变量声明。
+// "in"和setter的"set"一样不是关键字，IDENTIFIER后面紧跟另一个IDENTIFIER在C风格的for循环中不合法，所以不会有歧义
+func (p *Parser) isForIn() bool {
+	i := p.current
+	if p.at(i).Type == token.VAR {
+		i++
+	}
+
+	return p.at(i).Type == token.IDENTIFIER && p.at(i+1).Type == token.IDENTIFIER && p.at(i+1).Lexeme == "in"
+}
+
+// forInStmt -> "for" "(" "var"? IDENTIFIER "in" expression ")" statement
+func (p *Parser) forInStmt(keyword *token.Token) (Stmt, error) {
+	// 循环变量总是一个新的变量，"var"可以省略
+	p.match(token.VAR)
+	name := p.advance()
+	p.advance()
+
+	iterable, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.RIGHT_PAREN, "Expect ')' after for-in clause.")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewForInStmt(keyword, name, iterable, body), nil
+}
+
 // returnStmt -> "return" (expression)? ;
 func (p *Parser) returnStmt() (Stmt, error) {
 	keyword := p.previous()
@@ -365,40 +544,151 @@ func (p *Parser) returnStmt() (Stmt, error) {
 	return NewReturnStmt(keyword, value), err
 }
 
+// yieldStmt -> "yield" (expression)? ;
+func (p *Parser) yieldStmt() (Stmt, error) {
+	keyword := p.previous()
+	var value Expr
+	var err error
+	if !p.check(token.SEMICOLON) {
+		value, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = p.consume(token.SEMICOLON, "Expect ';' after yield value.")
+
+	return NewYieldStmt(keyword, value), err
+}
+
 // expression -> assignment
 func (p *Parser) expression() (Expr, error) {
 	return p.assignment()
 }
 
-// assignment -> ( call "." )? IDENTIFIER "=" assignment | logicOr
+// assignment -> ( call "." )? IDENTIFIER ( "=" | "+=" | "-=" | "*=" | "/=" ) assignment | conditional
 func (p *Parser) assignment() (Expr, error) {
 	// 赋值表达式 = 号左侧其实是一个"伪表达式"，是一个经过计算可以赋值的"东西"，所以这里要先对左侧进行求值
-	// expr的计算结果可能是logicOr或者优先级比LogicOr更高的表达式，主要包括**getter表达式**和**primary**
-	expr, err := p.logicOr()
+	// expr的计算结果可能是conditional或者优先级比它更高的表达式，主要包括**getter表达式**和**primary**
+	expr, err := p.conditional()
 	if err != nil {
 		return nil, err
 	}
 
-	if p.match(token.EQUAL) {
+	if p.match(token.EQUAL, token.PLUS_EQUAL, token.MINUS_EQUAL, token.STAR_EQUAL, token.SLASH_EQUAL) {
 		equals := p.previous()
+		// 赋值是右结合的
 		value, err := p.assignment()
 		if err != nil {
 			return nil, err
 		}
-		// 只有左侧计算的结果是Variable的时候，才会进行赋值语句
-		if ve, ok := expr.(*Variable); ok {
-			// =号左侧表达式是一个Variable
-			return NewAssign(ve.Name, value), nil
-		} else if getter, ok := expr.(*Get); ok {
-			// =号左侧表达式是一个Getter，则返回Setter表达式
-			return NewSet(getter.Object, getter.Attribute, value), nil
+
+		return p.assignTarget(expr, equals, compoundOperator(equals), value)
+	}
+
+	// 如果右侧没有初始化表达式，那么相当于是一个conditional表达式
+	return expr, nil
+}
+
+// assignTarget 根据=号左侧的表达式构造对应的赋值表达式，operator为nil时表示普通的赋值
+func (p *Parser) assignTarget(target Expr, equals, operator *token.Token, value Expr) (Expr, error) {
+	switch target := target.(type) {
+	case *Variable:
+		// =号左侧表达式是一个Variable
+		return NewAssign(target.Name, equals, operator, value), nil
+	case *Get:
+		// =号左侧表达式是一个Getter，则返回Setter表达式
+		return NewSet(target.Object, target.Attribute, equals, operator, value), nil
+	case *Index:
+		return NewIndexSet(target.Object, target.Bracket, target.Index, equals, operator, value), nil
+	}
+
+	// 左侧不能赋值时不需要同步，报告错误之后把左侧的表达式当作结果继续解析
+	p.report(p.error(equals, "Invalid assignment target."))
+
+	return target, nil
+}
+
+// compoundOperator 返回复合赋值运算符（+=, ++等）对应的二元运算符，普通赋值返回nil
+func compoundOperator(operator *token.Token) *token.Token {
+	switch operator.Type {
+	case token.PLUS_EQUAL, token.PLUS_PLUS:
+		return derivedToken(operator, token.PLUS, "+")
+	case token.MINUS_EQUAL, token.MINUS_MINUS:
+		return derivedToken(operator, token.MINUS, "-")
+	case token.STAR_EQUAL:
+		return derivedToken(operator, token.STAR, "*")
+	case token.SLASH_EQUAL:
+		return derivedToken(operator, token.SLASH, "/")
+	}
+
+	return nil
+}
+
+// increment 返回 "++" 和 "--" 的步长，scanner把它保存在token的Literal中，类型和整数字面量相同
+func increment(operator *token.Token) interface{} {
+	if operator.Literal != nil {
+		return operator.Literal
+	}
+
+	return float64(1)
+}
+
+// derivedToken 生成一个和operator位置相同的token
+func derivedToken(operator *token.Token, tokenType token.TokenType, lexeme string) *token.Token {
+	t := token.NewToken(tokenType, lexeme, nil, operator.Line)
+	t.Column, t.Offset = operator.Column, operator.Offset
+
+	return t
+}
+
+// conditional -> nullish ( "?" expression ":" conditional )?
+func (p *Parser) conditional() (Expr, error) {
+	expr, err := p.nullish()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.match(token.QUESTION) {
+		return expr, nil
+	}
+
+	question := p.previous()
+	// "?" 和 ":" 之间相当于有括号，可以是任意的表达式
+	thenBranch, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consume(token.COLON, "Expect ':' after then branch of conditional expression."); err != nil {
+		return nil, err
+	}
+
+	// 条件表达式是右结合的，a ? b : c ? d : e 相当于 a ? b : (c ? d : e)
+	elseBranch, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewConditional(expr, question, thenBranch, elseBranch), nil
+}
+
+// nullish -> logicOr ( "??" logicOr )*
+func (p *Parser) nullish() (Expr, error) {
+	expr, err := p.logicOr()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.QUESTION_QUESTION) {
+		operator := p.previous()
+		right, err := p.logicOr()
+		if err != nil {
+			return nil, err
 		}
 
-		// panic(loxerror.NewParseError(equals, "Invalid assignment target."))
-		return nil, loxerror.NewParseError(equals, "Invalid assignment target.")
+		// 和and/or一样短路求值，左侧不是nil的时候不计算右侧
+		expr = NewLogic(expr, operator, right)
 	}
 
-	// 如果右侧没有初始化表达式，那么相当于是一个logicOr表达式
 	return expr, nil
 }
 
@@ -465,14 +755,95 @@ func (p *Parser) equality() (Expr, error) {
 	return expr, nil
 }
 
-// comparison -> term ( (">" | ">=" | "<" | "<=") term )*
+// comparison -> bitOr ( (">" | ">=" | "<" | "<=") bitOr )*
 func (p *Parser) comparison() (Expr, error) {
-	expr, err := p.term()
+	expr, err := p.bitOr()
 	if err != nil {
 		return nil, err
 	}
 
 	for p.match(token.GREATER, token.GREATER_EQUAL, token.LESS, token.LESS_EQUAL) {
+		operator := p.previous()
+		right, err := p.bitOr()
+		if err != nil {
+			return nil, err
+		}
+
+		expr = NewBinary(expr, operator, right)
+	}
+
+	return expr, nil
+}
+
+// bitOr -> bitXor ( "|" bitXor )*
+// 位运算的优先级比比较运算高（和Python一样），所以 a & 1 == 0 相当于 (a & 1) == 0
+func (p *Parser) bitOr() (Expr, error) {
+	expr, err := p.bitXor()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.PIPE) {
+		operator := p.previous()
+		right, err := p.bitXor()
+		if err != nil {
+			return nil, err
+		}
+
+		expr = NewBinary(expr, operator, right)
+	}
+
+	return expr, nil
+}
+
+// bitXor -> bitAnd ( "^" bitAnd )*
+func (p *Parser) bitXor() (Expr, error) {
+	expr, err := p.bitAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.CARET) {
+		operator := p.previous()
+		right, err := p.bitAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		expr = NewBinary(expr, operator, right)
+	}
+
+	return expr, nil
+}
+
+// bitAnd -> shift ( "&" shift )*
+func (p *Parser) bitAnd() (Expr, error) {
+	expr, err := p.shift()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.AMPERSAND) {
+		operator := p.previous()
+		right, err := p.shift()
+		if err != nil {
+			return nil, err
+		}
+
+		expr = NewBinary(expr, operator, right)
+	}
+
+	return expr, nil
+}
+
+// shift -> term ( ("<<" | ">>") term )*
+func (p *Parser) shift() (Expr, error) {
+	expr, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(token.LESS_LESS, token.GREATER_GREATER) {
 		operator := p.previous()
 		right, err := p.term()
 		if err != nil {
@@ -505,14 +876,14 @@ func (p *Parser) term() (Expr, error) {
 	return expr, nil
 }
 
-// factor -> unary ( ("*" | "/") unary )*
+// factor -> unary ( ("*" | "/" | "%") unary )*
 func (p *Parser) factor() (Expr, error) {
 	expr, err := p.unary()
 	if err != nil {
 		return nil, err
 	}
 
-	for p.match(token.STAR, token.SLASH) {
+	for p.match(token.STAR, token.SLASH, token.PERCENT) {
 		operator := p.previous()
 		right, err := p.unary()
 		if err != nil {
@@ -525,18 +896,49 @@ func (p *Parser) factor() (Expr, error) {
 	return expr, nil
 }
 
-// unary -> ("!" | "-") unary | call
+// unary -> ("!" | "-" | "~") unary | ( "++" | "--" ) unary | postfix
 func (p *Parser) unary() (Expr, error) {
-	if p.match(token.BANG, token.MINUS) {
+	if p.match(token.BANG, token.MINUS, token.TILDE) {
 		operator := p.previous()
 		right, err := p.unary()
 		return NewUnary(operator, right), err
 	}
 
-	return p.call()
+	// 前缀自增/自减相当于 a += 1，表达式的值为赋值之后的新值
+	if p.match(token.PLUS_PLUS, token.MINUS_MINUS) {
+		operator := p.previous()
+		target, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		return p.assignTarget(target, operator, compoundOperator(operator), NewLiteral(increment(operator)))
+	}
+
+	return p.postfix()
 }
 
-// call -> primary ( "(" arguments? ")" | "." IDENTIFIER )*
+// postfix -> call ( "++" | "--" )?
+func (p *Parser) postfix() (Expr, error) {
+	expr, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(token.PLUS_PLUS, token.MINUS_MINUS) {
+		operator := p.previous()
+		target, err := p.assignTarget(expr, operator, compoundOperator(operator), NewLiteral(increment(operator)))
+		if err != nil {
+			return nil, err
+		}
+
+		return NewPostfix(target), nil
+	}
+
+	return expr, nil
+}
+
+// call -> primary ( "(" arguments? ")" | "." IDENTIFIER | "[" expression "]" | "?." ( IDENTIFIER | "(" arguments? ")" ) )*
 // 函数调用的优先级仅次于 primary,
 // 函数调用本身也可以是callee，如 funcall()()()，从文法角度上说就是 IDENTIFIER + ( "(" arguments? ")" )*
 // 一个 argument 本身就是一个 expression, 所以不需要再重新定义它的文法，只需要在解析函数调用的同时解析函数参数即可,
@@ -547,38 +949,15 @@ func (p *Parser) call() (Expr, error) {
 		return nil, err
 	}
 
+	// 调用链中出现过 "?."，整个链需要用OptionalChain包起来
+	optional := false
 	for {
 		if p.match(token.LEFT_PAREN) {
-			var arguments []Expr
-			// 当前Token如果不是 ")"，则说明有参数
-			if !p.check(token.RIGHT_PAREN) {
-				for {
-					// 限制最大参数量为255
-					if len(arguments) >= 255 {
-						//panic(loxerror.NewParseError(p.peek(), "Can't have more than 255 arguments."))
-						return nil, loxerror.NewParseError(p.peek(), "Can't have more than 255 arguments.")
-					}
-					// 添加参数
-					argument, err := p.expression()
-					if err != nil {
-						return nil, err
-					}
-
-					arguments = append(arguments, argument)
-					// 参数之间要以 "," 隔开
-					if !p.match(token.COMMA) {
-						break
-					}
-				}
-			}
-			// consume掉 ")"
-			paren, err := p.consume(token.RIGHT_PAREN, "Expect ')' after arguments.")
+			// 不断迭代expr
+			expr, err = p.finishCall(expr, false)
 			if err != nil {
 				return nil, err
 			}
-
-			// 不断迭代expr
-			expr = NewCall(expr, paren, arguments)
 		} else if p.match(token.DOT) {
 			attribute, err := p.consume(token.IDENTIFIER, "Expect attribute name after '.'.")
 			if err != nil {
@@ -586,31 +965,96 @@ func (p *Parser) call() (Expr, error) {
 			}
 
 			// 还是不断迭代expr
-			expr = NewGet(expr, attribute)
+			expr = NewGet(expr, attribute, false)
+		} else if p.match(token.QUESTION_DOT) {
+			optional = true
+			if p.match(token.LEFT_PAREN) {
+				expr, err = p.finishCall(expr, true)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			attribute, err := p.consume(token.IDENTIFIER, "Expect attribute name or '(' after '?.'.")
+			if err != nil {
+				return nil, err
+			}
+
+			expr = NewGet(expr, attribute, true)
+		} else if p.match(token.LEFT_BRACKET) {
+			index, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+
+			bracket, err := p.consume(token.RIGHT_BRACKET, "Expect ']' after index.")
+			if err != nil {
+				return nil, err
+			}
+
+			expr = NewIndex(expr, bracket, index)
 		} else {
-			// 如果 "(" 和 "." 都匹配不到，直接break，说明是一个primary
+			// 如果 "(", ".", "?." 和 "[" 都匹配不到，直接break，说明是一个primary
 			break
 		}
 	}
 
+	if optional {
+		return NewOptionalChain(expr), nil
+	}
+
 	return expr, nil
 }
 
-// primary -> NUMBER | STRING | "true" | "false" | "nil" | "return" | "(" expression ")" ｜ IDENTIFIER | "this" | super "." IDENTIFIER
+// finishCall 解析 "(" 之后的参数列表和 ")"
+func (p *Parser) finishCall(callee Expr, optional bool) (Expr, error) {
+	var arguments []Expr
+	// 当前Token如果不是 ")"，则说明有参数
+	if !p.check(token.RIGHT_PAREN) {
+		for {
+			// 限制最大参数量为255
+			if len(arguments) == 255 {
+				p.report(p.error(p.peek(), "Can't have more than 255 arguments."))
+			}
+			// 添加参数
+			argument, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+
+			arguments = append(arguments, argument)
+			// 参数之间要以 "," 隔开
+			if !p.match(token.COMMA) {
+				break
+			}
+		}
+	}
+	// consume掉 ")"
+	paren, err := p.consume(token.RIGHT_PAREN, "Expect ')' after arguments.")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCall(callee, paren, arguments, optional), nil
+}
+
+// primary -> NUMBER | STRING | "true" | "false" | "nil" | "return" | "(" expression ")" ｜ IDENTIFIER | "this" | super "." IDENTIFIER | lambda | "[" arguments? "]" | map
+//
 // #### "super" isn't allowed to appear alone ###
 func (p *Parser) primary() (Expr, error) {
 	if p.match(token.TRUE) {
-		return NewLiteral(true), nil
+		return NewTokenLiteral(true, p.previous()), nil
 	}
 	if p.match(token.FALSE) {
-		return NewLiteral(false), nil
+		return NewTokenLiteral(false, p.previous()), nil
 	}
 	if p.match(token.NIL) {
-		return NewLiteral(nil), nil
+		return NewTokenLiteral(nil, p.previous()), nil
 	}
 
 	if p.match(token.NUMBER, token.STRING) {
-		return NewLiteral(p.previous().Literal), nil
+		return NewTokenLiteral(p.previous().Literal, p.previous()), nil
 	}
 
 	if p.match(token.IDENTIFIER) {
@@ -633,6 +1077,37 @@ func (p *Parser) primary() (Expr, error) {
 		return NewSuper(keyword, identifier), err
 	}
 
+	if p.match(token.LEFT_BRACKET) {
+		bracket := p.previous()
+		var elements []Expr
+		if !p.check(token.RIGHT_BRACKET) {
+			for {
+				element, err := p.expression()
+				if err != nil {
+					return nil, err
+				}
+
+				elements = append(elements, element)
+				if !p.match(token.COMMA) {
+					break
+				}
+			}
+		}
+
+		_, err := p.consume(token.RIGHT_BRACKET, "Expect ']' after list elements.")
+
+		return NewList(bracket, elements), err
+	}
+
+	// statement开头的 "{" 在statement()中已经被当作block，所以这里的 "{" 一定是map字面量
+	if p.match(token.LEFT_BRACE) {
+		return p.mapLiteral()
+	}
+
+	if p.check(token.FUN) || (p.check(token.LEFT_PAREN) && p.isArrowFunction()) {
+		return p.lambda()
+	}
+
 	if p.match(token.LEFT_PAREN) {
 		expr, err := p.expression()
 		if err != nil {
@@ -644,6 +1119,111 @@ func (p *Parser) primary() (Expr, error) {
 		return NewGrouping(expr), err
 	}
 
-	//panic(loxerror.NewParseError(p.peek(), "Unknown expression."))
-	return nil, loxerror.NewParseError(p.peek(), "Unknown expression.")
+	err := p.error(p.peek(), "Expect expression, found "+describe(p.peek())+".")
+	if p.tolerant {
+		return p.badExpr(err), nil
+	}
+
+	return nil, err
+}
+
+// map -> "{" ( expression ":" expression ( "," expression ":" expression )* )? "}"
+func (p *Parser) mapLiteral() (Expr, error) {
+	brace := p.previous()
+	var keys, values []Expr
+	if !p.check(token.RIGHT_BRACE) {
+		for {
+			key, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = p.consume(token.COLON, "Expect ':' after map key.")
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+
+			keys, values = append(keys, key), append(values, value)
+			if !p.match(token.COMMA) {
+				break
+			}
+		}
+	}
+
+	_, err := p.consume(token.RIGHT_BRACE, "Expect '}' after map entries.")
+
+	return NewMap(brace, keys, values), err
+}
+
+// lambda -> "fun" "(" parameters? ")" block | "(" parameters? ")" "=>" ( block | expression )
+func (p *Parser) lambda() (Expr, error) {
+	if p.match(token.FUN) {
+		keyword := p.previous()
+		parameters, body, err := p.functionBody("anonymous function")
+		if err != nil {
+			return nil, err
+		}
+
+		return NewLambda(keyword, NewFuncDeclStmt(keyword, nil, parameters, body)), nil
+	}
+
+	// consume掉 "("
+	p.advance()
+	parameters, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+
+	arrow, err := p.consume(token.ARROW, "Expect '=>' after parameters.")
+	if err != nil {
+		return nil, err
+	}
+
+	var body *BlockStmt
+	if p.match(token.LEFT_BRACE) {
+		stmts, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+
+		body = NewBlockStmt(stmts)
+	} else {
+		// 箭头函数的函数体是一个表达式时，相当于直接return这个表达式的值
+		value, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+
+		body = NewBlockStmt([]Stmt{NewReturnStmt(arrow, value)})
+	}
+
+	return NewLambda(arrow, NewFuncDeclStmt(nil, nil, parameters, body)), nil
+}
+
+// isArrowFunction 向前看，判断current指向的 "(" 是否为箭头函数参数列表的开始，不会consume任何Token
+func (p *Parser) isArrowFunction() bool {
+	i := p.current + 1
+	if p.at(i).Type != token.RIGHT_PAREN {
+		for {
+			if p.at(i).Type != token.IDENTIFIER {
+				return false
+			}
+			i++
+			if p.at(i).Type != token.COMMA {
+				break
+			}
+			i++
+		}
+
+		if p.at(i).Type != token.RIGHT_PAREN {
+			return false
+		}
+	}
+
+	return p.at(i+1).Type == token.ARROW
 }
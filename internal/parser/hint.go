@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"GLox/internal/scanner"
+	"GLox/internal/scanner/token"
+	"sort"
+	"strings"
+)
+
+// keywordHint 依次在出错位置前面的token、出错的token以及当前declaration的第一个token中查找拼错的关键字，
+// 比如 "retrun x;" 或者 "whle (x) {}"，找不到的时候返回空字符串
+func (p *Parser) keywordHint(at *token.Token) string {
+	var candidates []int
+	for i, t := range p.tokens {
+		if t == at {
+			candidates = append(candidates, i-1, i)
+			break
+		}
+	}
+	candidates = append(candidates, p.start)
+
+	for _, i := range candidates {
+		if i < 0 || i >= len(p.tokens) || p.tokens[i].Type != token.IDENTIFIER {
+			continue
+		}
+
+		// 两个identifier不会相邻，这时第一个多半是关键字，比如 "function foo()"
+		followed := p.at(i+1).Type == token.IDENTIFIER
+		if keyword := similarKeyword(p.tokens[i].Lexeme, followed); keyword != "" {
+			return keyword
+		}
+	}
+
+	return ""
+}
+
+// similarKeyword 返回和word相近的关键字。短的单词很容易和关键字只差一个字母（比如foo和for），
+// 所以3个字母的单词只提示交换了相邻字母的情况
+func similarKeyword(word string, prefix bool) string {
+	for _, keyword := range scanner.Keywords() {
+		switch distance := editDistance(word, keyword); {
+		case distance == 1 && (len(word) >= 4 || sameLetters(word, keyword)), distance == 2 && len(word) >= 6:
+			return keyword
+		case prefix && len(keyword) >= 3 && strings.HasPrefix(word, keyword):
+			return keyword
+		}
+	}
+
+	return ""
+}
+
+// editDistance 计算两个字符串的编辑距离（Damerau-Levenshtein的OSA版本，相邻字符交换算作一次编辑）
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = minInt(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(a)][len(b)]
+}
+
+// sameLetters 判断a和b是否由相同的字母组成
+func sameLetters(a, b string) bool {
+	sa, sb := []byte(a), []byte(b)
+	sort.Slice(sa, func(i, j int) bool { return sa[i] < sa[j] })
+	sort.Slice(sb, func(i, j int) bool { return sb[i] < sb[j] })
+
+	return string(sa) == string(sb)
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
@@ -0,0 +1,47 @@
+package parser
+
+// Walk 按深度优先的顺序遍历语法树，和 go/ast.Inspect 一样：先对node调用fn，fn返回true时继续遍历node的子节点，
+// 所有子节点遍历完之后再调用一次fn(nil)
+func Walk(node Node, fn func(Node) bool) {
+	if node == nil || !fn(node) {
+		return
+	}
+
+	WalkChildren(node, func(child Node) {
+		Walk(child, fn)
+	})
+	fn(nil)
+}
+
+// WalkProgram 依次遍历每一个顶层的statement
+func WalkProgram(program []Stmt, fn func(Node) bool) {
+	for _, stmt := range program {
+		Walk(stmt, fn)
+	}
+}
+
+// Rewrite 自底向上地改写语法树：先改写node的子节点，再用fn(node)的返回值替换node本身。
+// fn返回它的参数表示不修改，返回nil表示删除这个节点（见RewriteChildren）。语法树是原地修改的，返回新的根节点
+func Rewrite(node Node, fn func(Node) Node) Node {
+	if node == nil {
+		return nil
+	}
+
+	RewriteChildren(node, func(child Node) Node {
+		return Rewrite(child, fn)
+	})
+
+	return fn(node)
+}
+
+// RewriteProgram 改写每一个顶层的statement，被删除的statement不会出现在结果中
+func RewriteProgram(program []Stmt, fn func(Node) Node) []Stmt {
+	var stmts []Stmt
+	for _, stmt := range program {
+		if stmt, ok := Rewrite(stmt, fn).(Stmt); ok && stmt != nil {
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	return stmts
+}
@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"GLox/internal/scanner/token"
+	"reflect"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	program := parseSource(t, `fun f(x) { if (x) return x + 1; }
+print f(2) * 3;`)
+
+	var names []string
+	var enter, leave int
+	WalkProgram(program, func(node Node) bool {
+		if node == nil {
+			leave++
+			return true
+		}
+		enter++
+
+		switch n := node.(type) {
+		case *Variable:
+			names = append(names, n.Name.Lexeme)
+		case *Binary:
+			// 不进入乘法的子节点
+			return n.Operator.Type != token.STAR
+		}
+		return true
+	})
+
+	if want := []string{"x", "x"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+	// 返回false的节点不会有对应的fn(nil)
+	if enter != leave+1 {
+		t.Errorf("enter = %d, leave = %d", enter, leave)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	program := parseSource(t, `print 1 + 2;
+print "a";
+var b = -3;`)
+
+	// 把所有的数字字面量加倍，删除字符串的print语句
+	program = RewriteProgram(program, func(node Node) Node {
+		switch n := node.(type) {
+		case *Literal:
+			if value, ok := n.Value.(float64); ok {
+				return NewTokenLiteral(value*2, n.Token)
+			}
+		case *PrintStmt:
+			if literal, ok := n.Expr.(*Literal); ok {
+				if _, ok := literal.Value.(string); ok {
+					return nil
+				}
+			}
+		}
+		return node
+	})
+
+	if got, want := (&Printer{}).PrintProgram(program), "(print (+ 2 4))\n(var b (- 6))"; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPosition(t *testing.T) {
+	program := parseSource(t, `var a = foo(1,
+  "b");
+class C { m() { return this.x; } }
+a++;`)
+
+	tests := []struct {
+		node     Node
+		pos, end token.Position
+	}{
+		// declaration从关键字开始
+		{program[0], token.Position{Line: 1, Column: 1}, token.Position{Line: 2, Column: 7}},
+		{program[0].(*VarDeclStmt).Initializer, token.Position{Line: 1, Column: 9}, token.Position{Line: 2, Column: 7}},
+		{program[1], token.Position{Line: 3, Column: 1}, token.Position{Line: 3, Column: 30}},
+		// 方法没有关键字，从方法名开始
+		{program[1].(*ClassDeclStmt).Methods[0], token.Position{Line: 3, Column: 11}, token.Position{Line: 3, Column: 30}},
+		// 包括"++"本身，而不只是脱糖生成的"+"
+		{program[2], token.Position{Line: 4, Column: 1}, token.Position{Line: 4, Column: 4}},
+	}
+	for i, test := range tests {
+		if pos, end := test.node.Pos(), test.node.End(); pos != test.pos || end != test.end {
+			t.Errorf("%d: got %v-%v, want %v-%v", i, pos, end, test.pos, test.end)
+		}
+	}
+
+	if pos := NewLiteral(1.0).Pos(); pos.IsValid() {
+		t.Errorf("literal without token should have no position, got %v", pos)
+	}
+
+	// Pos和End每次都从语法树计算，Rewrite或者直接修改节点之后范围随之改变
+	stmt := parseSource(t, "1 + foo;")[0]
+	if pos := stmt.Pos(); pos.Column != 1 {
+		t.Fatalf("got %v, want column 1", pos)
+	}
+	Rewrite(stmt, func(node Node) Node {
+		if n, ok := node.(*Binary); ok {
+			return n.Right
+		}
+		return node
+	})
+	if pos := stmt.Pos(); pos.Column != 5 {
+		t.Errorf("after rewrite got %v, want column 5", pos)
+	}
+
+	binary := parseSource(t, "foo + 1 + 22;")[0].(*ExprStmt).Expr.(*Binary)
+	if end := binary.End(); end.Column != 13 {
+		t.Fatalf("got end %v, want column 13", end)
+	}
+	binary.Right = binary.Left
+	if end := binary.End(); end.Column != 10 {
+		t.Errorf("after assigning Right got end %v, want column 10", end)
+	}
+}
+
+// 匿名函数没有名字，行号取自"fun"、第一个参数，或者函数体（表达式形式的函数体是"=>"所在的行）
+func TestLambdaLine(t *testing.T) {
+	program := parseSource(t, `var f = fun (a) {
+  return a;
+};
+var g =
+  (x) => x;
+var h = () =>
+  1;`)
+
+	for i, want := range []int{1, 5, 6} {
+		lambda := program[i].(*VarDeclStmt).Initializer.(*Lambda)
+		if got := lambda.Function.Line(); got != want {
+			t.Errorf("%d: got line %d, want %d", i, got, want)
+		}
+	}
+}
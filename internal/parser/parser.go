@@ -3,11 +3,22 @@ package parser
 import (
 	"GLox/internal/loxerror"
 	"GLox/internal/scanner/token"
+	"strings"
 )
 
 type Parser struct {
 	tokens  []*token.Token
 	current int
+	start   int                  // 当前declaration的第一个token
+	errors  loxerror.ParseErrors // 解析过程中遇到的所有错误
+	// tolerant 为true时用BadExpr和BadStmt代替出错的部分，而不是直接丢弃，见file.go
+	tolerant bool
+	// lookahead 是解析过程中看过的最远的token的下标，File.Edit用它判断declaration是否依赖后面的token
+	lookahead int
+	// firstError 是当前declaration的第一个错误在errors中的下标。lookbehind表示当前declaration中有错误
+	// 因为和前一个declaration的最后一个错误位置相同而没有记录，也就是它的错误依赖前一个declaration
+	firstError int
+	lookbehind bool
 }
 
 func NewParser(tokens []*token.Token) *Parser {
@@ -34,12 +45,31 @@ func (p *Parser) check(t token.TokenType) bool {
 	return p.peek().Type == t
 }
 
+// checkNext 判断current之后的下一个Token类型和传入的类型t是否匹配
+func (p *Parser) checkNext(t token.TokenType) bool {
+	if p.isAtEnd() || p.at(p.current+1).Type == token.EOF {
+		return false
+	}
+	return p.at(p.current+1).Type == t
+}
+
+// isAtEnd 判断current是否指向最后的EOF，这也算看过了current指向的token
 func (p *Parser) isAtEnd() bool {
+	p.at(p.current)
 	return p.current >= len(p.tokens)-1
 }
 
 func (p *Parser) peek() *token.Token {
-	return p.tokens[p.current]
+	return p.at(p.current)
+}
+
+// at 返回下标为i的token，并且记录看过的最远的位置。向前看的时候都要通过at访问tokens
+func (p *Parser) at(i int) *token.Token {
+	if i > p.lookahead {
+		p.lookahead = i
+	}
+
+	return p.tokens[i]
 }
 
 func (p *Parser) advance() *token.Token {
@@ -54,26 +84,109 @@ func (p *Parser) previous() *token.Token {
 	return p.tokens[p.current-1]
 }
 
-// 判断current指向的Token是不是传入的t，如果不是则panic，如果是则返回当前token，然后current+1
+// 判断current指向的Token是不是传入的t，如果是则返回当前token，然后current+1，否则返回错误，
+// msg是期望的内容（如 "Expect ';' after value."），错误信息中会加上实际遇到的token
 func (p *Parser) consume(t token.TokenType, msg string) (*token.Token, error) {
 	if p.check(t) {
 		return p.advance(), nil
 	}
 
-	//panic(loxerror.NewParseError(p.peek(), msg))
-	return nil, loxerror.NewParseError(p.peek(), msg)
+	return nil, p.error(p.peek(), strings.TrimSuffix(msg, ".")+", found "+describe(p.peek())+".")
 }
 
-func (p *Parser) synchronize() {
-	p.advance()
+// error 生成一个ParseError，出错位置附近有拼错的关键字时在message后面加上提示，见hint.go
+func (p *Parser) error(t *token.Token, message string) *loxerror.ParseError {
+	if keyword := p.keywordHint(t); keyword != "" {
+		message += " Did you mean '" + keyword + "'?"
+	}
+
+	return loxerror.NewParseError(t, message)
+}
+
+// report 记录一个错误但是不中断解析，用于不影响后续文法的错误，比如参数过多
+func (p *Parser) report(err error) {
+	if err, ok := err.(*loxerror.ParseError); ok {
+		// 容错解析时缺少表达式之后通常还会缺少 ';' 等，同一个位置只保留第一个错误
+		if n := len(p.errors); n > 0 && p.errors[n-1].Token() == err.Token() {
+			if n-1 < p.firstError {
+				p.lookbehind = true
+			}
+			return
+		}
+		p.errors = append(p.errors, err)
+	}
+}
+
+// recoverStmt 记录declaration中的错误，然后跳到下一个statement的开始，start是declaration第一个token的位置。
+// 容错解析时返回覆盖被跳过的token的BadStmt，否则返回nil
+func (p *Parser) recoverStmt(err error, start int) Stmt {
+	p.report(err)
+	// 一个token都没有consume的时候至少要跳过一个，否则会在同一个位置反复出错
+	if p.current == start {
+		p.advance()
+	}
+
+	p.synchronize()
+	if !p.tolerant {
+		return nil
+	}
+
+	return NewBadStmt(p.tokens[start], p.previous())
+}
+
+// badExpr 在容错解析时代替无法解析的表达式。出错的token如果是statement的边界（比如 ';' 或者 '}'）则留给外层consume，
+// 否则把它作为BadExpr的一部分跳过
+func (p *Parser) badExpr(err *loxerror.ParseError) Expr {
+	p.report(err)
+	t := p.peek()
+	switch t.Type {
+	case token.SEMICOLON, token.COMMA, token.COLON, token.RIGHT_PAREN, token.RIGHT_BRACE, token.RIGHT_BRACKET, token.EOF,
+		token.CLASS, token.TRAIT, token.FUN, token.VAR, token.FOR, token.IF, token.WHILE, token.PRINT, token.RETURN, token.YIELD:
+	default:
+		p.advance()
+	}
+
+	return NewBadExpr(t, t)
+}
 
+// synchronize 丢弃token，直到刚刚consume了 ';' 或者一个完整的 "{...}"，或者下一个token是statement的关键字或者 '}'
+// （由外层的block或者class body consume）。整个跳过 "{...}" 可以避免把函数体中的statement当作外层的statement
+func (p *Parser) synchronize() {
 	for !p.isAtEnd() {
-		if p.previous().Type == token.SEMICOLON {
+		switch p.peek().Type {
+		case token.SEMICOLON:
+			p.advance()
+			return
+		case token.RIGHT_BRACE:
+			return
+		case token.LEFT_BRACE:
+			p.skipBlock()
+			return
+		case token.CLASS, token.TRAIT, token.FUN, token.VAR, token.FOR, token.IF, token.WHILE, token.PRINT, token.RETURN, token.YIELD:
 			return
 		}
 
+		p.advance()
+	}
+}
+
+// recoverMember 记录类或者trait成员中的错误，然后跳过这个成员：直到consume了 ';' 或者一个完整的 "{...}"，
+// 或者遇到结束类的 '}'
+func (p *Parser) recoverMember(err error, start int) {
+	p.report(err)
+	if p.current == start {
+		p.advance()
+	}
+
+	for !p.isAtEnd() {
 		switch p.peek().Type {
-		case token.CLASS, token.FUN, token.VAR, token.FOR, token.IF, token.WHILE, token.PRINT, token.RETURN:
+		case token.SEMICOLON:
+			p.advance()
+			return
+		case token.LEFT_BRACE:
+			p.skipBlock()
+			return
+		case token.RIGHT_BRACE:
 			return
 		}
 
@@ -81,18 +194,46 @@ func (p *Parser) synchronize() {
 	}
 }
 
-// Parse 将一个程序（Token序列）解析成多个Stmt
-func (p *Parser) Parse() (stmts []Stmt) {
+// skipBlock 跳过从current开始的 "{...}"，包括其中嵌套的block
+func (p *Parser) skipBlock() {
+	depth := 0
+	for !p.isAtEnd() {
+		switch p.advance().Type {
+		case token.LEFT_BRACE:
+			depth++
+		case token.RIGHT_BRACE:
+			depth--
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+// Parse 将一个程序（Token序列）解析成多个Stmt。遇到语法错误时会跳到下一个statement继续解析，
+// 所有的错误按出现的顺序返回，有错误的时候返回的语法树是不完整的
+func (p *Parser) Parse() ([]Stmt, loxerror.ParseErrors) {
+	var stmts []Stmt
 	// 一个程序由多个declaration + EOF组成: program -> declaration* EOF
 	for !p.isAtEnd() {
+		start := p.current
 		stmt, err := p.declaration()
 		if err != nil {
-			println(err.Error())
-			p.synchronize()
-		} else {
+			stmt = p.recoverStmt(err, start)
+		}
+		if stmt != nil {
 			stmts = append(stmts, stmt)
 		}
 	}
 
-	return stmts
+	return stmts, p.errors
+}
+
+// describe 返回错误信息中对token的描述
+func describe(t *token.Token) string {
+	if t.Type == token.EOF {
+		return "end of file"
+	}
+
+	return "'" + t.Lexeme + "'"
 }
@@ -0,0 +1,183 @@
+package parser
+
+import (
+	"GLox/internal/scanner"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parseErrors(source string) ([]Stmt, []string) {
+	program, errs := NewParser(scanner.NewScanner(source).ScanTokens()).Parse()
+
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+
+	return program, messages
+}
+
+func TestParseErrorRecovery(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		errors []string
+		stmts  int
+	}{
+		{
+			"missing semicolon in method",
+			`class A {
+  foo() { print 1 }
+  bar() { print 2; }
+}
+print A;`,
+			[]string{"[parse error] line 2:19: Expect ';' after value, found '}'."},
+			2,
+		},
+		{
+			"bad member header",
+			`class A {
+  foo(a b) { print 1; }
+  bar() { print 2; }
+}`,
+			[]string{"[parse error] line 2:9: Expect ')' after parameters, found 'b'."},
+			1,
+		},
+		{
+			"errors in nested blocks",
+			`fun f() {
+  var = 1;
+  if (true) { print ; }
+  return 1;
+}
+f();`,
+			[]string{
+				"[parse error] line 2:7: Expect variable name, found '='.",
+				"[parse error] line 3:21: Expect expression, found ';'.",
+			},
+			2,
+		},
+		{
+			"stray brace",
+			"} print 1;",
+			[]string{"[parse error] line 1:1: Expect expression, found '}'."},
+			1,
+		},
+		{
+			"end of file",
+			"print (1",
+			[]string{"[parse error] line 1:9: Expect ')' after expression, found end of file."},
+			0,
+		},
+		{
+			"errors that don't need synchronization",
+			"1 = 2; print 3;",
+			[]string{"[parse error] line 1:3: Invalid assignment target."},
+			2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			program, errors := parseErrors(test.source)
+			if !reflect.DeepEqual(errors, test.errors) {
+				t.Errorf("errors:\n%q\nwant:\n%q", errors, test.errors)
+			}
+			if len(program) != test.stmts {
+				t.Errorf("got %d statements, want %d", len(program), test.stmts)
+			}
+		})
+	}
+}
+
+func TestKeywordHint(t *testing.T) {
+	tests := []struct {
+		source string
+		hint   string
+	}{
+		{"retrun 1;", "return"},
+		{"whle (true) {}", "while"},
+		{"function foo() {}", "fun"},
+		{"var a = 1\npirnt a;", "print"},
+		{"clas A {}", "class"},
+		{"var a = 1\nfoo(a);", ""},
+		{"class A { bar(a b) {} }", ""},
+	}
+
+	for _, test := range tests {
+		_, errs := NewParser(scanner.NewScanner(test.source).ScanTokens()).Parse()
+		if len(errs) == 0 {
+			t.Errorf("%q: expect an error", test.source)
+			continue
+		}
+
+		want := ""
+		if test.hint != "" {
+			want = " Did you mean '" + test.hint + "'?"
+		}
+		if message := errs[0].Message(); !strings.HasSuffix(message, want) || (want == "" && strings.HasSuffix(message, "?")) {
+			t.Errorf("%q: got %q, want hint %q", test.source, message, test.hint)
+		}
+	}
+}
+
+func TestConditionalPrecedence(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"a ? b : c ? d : e;", "(; (?: a b (?: c d e)))"},
+		{"a or b ? c : d;", "(; (?: (or a b) c d))"},
+		{"a ?? b or c;", "(; (?? a (or b c)))"},
+		{"a ?? b ? c : d;", "(; (?: (?? a b) c d))"},
+		{"x = a ? b = 1 : c;", "(; (= x (?: a (= b 1) c)))"},
+		{"a?.b.c(1);", "(; (call (. (?. a b) c) 1))"},
+		{"f?.(1)?.g;", "(; (?. (call?. f 1) g))"},
+	}
+
+	for _, test := range tests {
+		program, errors := parseErrors(test.source)
+		if len(errors) > 0 {
+			t.Errorf("%q: unexpected errors %v", test.source, errors)
+			continue
+		}
+		if got := new(Printer).PrintProgram(program); got != test.want {
+			t.Errorf("%q: got %s, want %s", test.source, got, test.want)
+		}
+	}
+
+	// 可选链不能作为赋值的目标
+	if _, errors := parseErrors("a?.b = 1;"); len(errors) != 1 || !strings.Contains(errors[0], "Invalid assignment target.") {
+		t.Errorf("got errors %v, want invalid assignment target", errors)
+	}
+}
+
+func TestForIn(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"for (x in xs) print x;", "(for-in x xs (print x))"},
+		{"for (var c in \"ab\") {}", "(for-in c ab (block))"},
+		{"for (k in {1: 2, \"a\": b}) {}", "(for-in k (map (: 1 2) (: a b)) (block))"},
+		// "in"不是关键字，仍然可以作为变量名
+		{"var in = 1; for (in = 0; in < 1; in++) {}", "(var in 1)\n(block (; (= in 0)) (while (< in 1) (block (block) (; (postfix (++ in))))))"},
+		{"print {};", "(print (map))"},
+	}
+
+	for _, test := range tests {
+		program, errors := parseErrors(test.source)
+		if len(errors) > 0 {
+			t.Errorf("%q: unexpected errors %v", test.source, errors)
+			continue
+		}
+		if got := new(Printer).PrintProgram(program); got != test.want {
+			t.Errorf("%q: got %s, want %s", test.source, got, test.want)
+		}
+	}
+
+	if _, errors := parseErrors("print {1 2};"); len(errors) == 0 || !strings.Contains(errors[0], "Expect ':' after map key") {
+		t.Errorf("got errors %v, want missing ':'", errors)
+	}
+}
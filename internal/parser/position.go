@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"GLox/internal/scanner/token"
+)
+
+// span 返回node中所有的token和子节点覆盖的范围，Pos和End的实现都基于它。
+// 没有保存在语法树中的token（比如括号、分号和"{"）不计算在内，脱糖生成的token没有位置，也会被忽略
+func span(node Node) (pos, end token.Position) {
+	extend := func(p, e token.Position) {
+		if p.IsValid() && (!pos.IsValid() || p.Before(pos)) {
+			pos = p
+		}
+		if e.IsValid() && (!end.IsValid() || end.Before(e)) {
+			end = e
+		}
+	}
+
+	walkTokens(node, func(t *token.Token) {
+		extend(t.Pos(), t.End())
+	})
+	WalkChildren(node, func(child Node) {
+		extend(span(child))
+	})
+
+	return pos, end
+}
+
+// Line 的实现，statement的行号取自它的第一个token，表达式语句取它最左侧表达式的token
+
+func (e *ExprStmt) Line() int { return exprLine(e.Expr) }
+
+// Line 匿名函数没有Name，"fun"形式的取Keyword，箭头函数取第一个参数或者函数体的行号
+func (f *FuncDeclStmt) Line() int {
+	switch {
+	case f.Keyword != nil:
+		return f.Keyword.Line
+	case f.Name != nil:
+		return f.Name.Line
+	case len(f.Params) > 0:
+		return f.Params[0].Line
+	case f.Body != nil:
+		return f.Body.Line()
+	}
+
+	return 0
+}
+
+func (c *ClassDeclStmt) Line() int { return c.Name.Line }
+
+func (t *TraitDeclStmt) Line() int { return t.Name.Line }
+
+func (r *ReturnStmt) Line() int { return r.Keyword.Line }
+
+func (y *YieldStmt) Line() int { return y.Keyword.Line }
+
+func (p *PrintStmt) Line() int { return p.Keyword.Line }
+
+func (v *VarDeclStmt) Line() int { return v.Name.Line }
+
+// Line 脱糖生成的block没有"{"，所以取第一个statement的行号
+func (b *BlockStmt) Line() int {
+	if len(b.Stmts) == 0 {
+		return 0
+	}
+
+	return b.Stmts[0].Line()
+}
+
+func (i *IfStmt) Line() int { return i.Keyword.Line }
+
+func (w *WhileStmt) Line() int { return w.Keyword.Line }
+
+func (f *ForInStmt) Line() int { return f.Keyword.Line }
+
+func (b *BadStmt) Line() int { return b.From.Line }
+
+// exprLine 返回表达式最左侧的token所在的行，脱糖生成的字面量没有token，返回0
+func exprLine(expr Expr) int {
+	switch e := expr.(type) {
+	case *Binary:
+		return exprLine(e.Left)
+	case *Grouping:
+		return exprLine(e.Expression)
+	case *Literal:
+		if e.Token != nil {
+			return e.Token.Line
+		}
+	case *Unary:
+		return e.Operator.Line
+	case *Variable:
+		return e.Name.Line
+	case *Assign:
+		return e.Name.Line
+	case *Logic:
+		return exprLine(e.Left)
+	case *Call:
+		return exprLine(e.Callee)
+	case *Get:
+		return exprLine(e.Object)
+	case *Set:
+		return exprLine(e.Object)
+	case *This:
+		return e.Keyword.Line
+	case *Super:
+		return e.Keyword.Line
+	case *Index:
+		return exprLine(e.Object)
+	case *IndexSet:
+		return exprLine(e.Object)
+	case *Postfix:
+		return exprLine(e.Target)
+	case *Lambda:
+		return e.Keyword.Line
+	case *List:
+		return e.Bracket.Line
+	case *Map:
+		return e.Brace.Line
+	case *Conditional:
+		return exprLine(e.Condition)
+	case *OptionalChain:
+		return exprLine(e.Expression)
+	case *BadExpr:
+		return e.From.Line
+	}
+
+	return 0
+}
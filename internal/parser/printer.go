@@ -1,12 +1,15 @@
 package parser
 
 import (
+	"GLox/internal/scanner/token"
 	"GLox/utils"
 	"bytes"
+	"strings"
 )
 
-// Printer ExprVisitor 子类之一，以特殊的形式打印出语法树上的节点
+// Printer ExprVisitor 和 StmtVisitor 子类之一，以s-expression的形式打印出语法树上的节点
 type Printer struct {
+	output string // StmtVisitor的方法只能返回error，语句的打印结果暂存在这里
 }
 
 func (p *Printer) VisitBinaryExpr(expr *Binary) (interface{}, error) {
@@ -30,60 +33,231 @@ func (p *Printer) VisitUnaryExpr(expr *Unary) (interface{}, error) {
 }
 
 func (p *Printer) VisitVariableExpr(expr *Variable) (interface{}, error) {
-	// empty implementation
-
-	return nil, nil
+	return expr.Name.Lexeme, nil
 }
 
 func (p *Printer) VisitAssignExpr(expr *Assign) (interface{}, error) {
-	// empty implementation
-
-	return nil, nil
+	return p.assign(expr.Equals, expr.Name.Lexeme, expr.Value), nil
 }
 
 func (p *Printer) VisitLogicExpr(expr *Logic) (interface{}, error) {
-	// empty implementation
-
-	return nil, nil
+	return p.parenthesize(expr.Operator.Lexeme, expr.Left, expr.Right), nil
 }
 
 func (p *Printer) VisitCallExpr(expr *Call) (interface{}, error) {
-	// empty implementation
+	parts := []interface{}{expr.Callee}
+	for _, argument := range expr.Arguments {
+		parts = append(parts, argument)
+	}
 
-	return nil, nil
+	return p.parenthesize(utils.Ternary(expr.Optional, "call?.", "call"), parts...), nil
 }
 
 func (p *Printer) VisitGetExpr(expr *Get) (interface{}, error) {
-	// empty implementation
-
-	return nil, nil
+	return p.parenthesize(utils.Ternary(expr.Optional, "?.", "."), expr.Object, expr.Attribute.Lexeme), nil
 }
 
 func (p *Printer) VisitSetExpr(expr *Set) (interface{}, error) {
-	// empty implementation
+	target := p.parenthesize(".", expr.Object, expr.Attribute.Lexeme)
 
-	return nil, nil
+	return p.assign(expr.Equals, target, expr.Value), nil
 }
 
 func (p *Printer) VisitThisExpr(expr *This) (interface{}, error) {
-	// empty implementation
-
-	return nil, nil
+	return "this", nil
 }
 
 func (p *Printer) VisitSuperExpr(expr *Super) (interface{}, error) {
-	// empty implementation
+	return p.parenthesize("super", expr.Identifier.Lexeme), nil
+}
+
+func (p *Printer) VisitIndexExpr(expr *Index) (interface{}, error) {
+	return p.parenthesize("[]", expr.Object, expr.Index), nil
+}
+
+func (p *Printer) VisitIndexSetExpr(expr *IndexSet) (interface{}, error) {
+	target := p.parenthesize("[]", expr.Object, expr.Index)
+
+	return p.assign(expr.Equals, target, expr.Value), nil
+}
+
+func (p *Printer) VisitPostfixExpr(expr *Postfix) (interface{}, error) {
+	return p.parenthesize("postfix", expr.Target), nil
+}
+
+func (p *Printer) VisitLambdaExpr(expr *Lambda) (interface{}, error) {
+	return p.function("lambda", expr.Function), nil
+}
+
+func (p *Printer) VisitListExpr(expr *List) (interface{}, error) {
+	var parts []interface{}
+	for _, element := range expr.Elements {
+		parts = append(parts, element)
+	}
+
+	return p.parenthesize("list", parts...), nil
+}
+
+func (p *Printer) VisitMapExpr(expr *Map) (interface{}, error) {
+	var parts []interface{}
+	for i, key := range expr.Keys {
+		parts = append(parts, p.parenthesize(":", key, expr.Values[i]))
+	}
 
-	return nil, nil
+	return p.parenthesize("map", parts...), nil
 }
 
-func (p *Printer) parenthesize(name string, exprs ...Expr) string {
+func (p *Printer) VisitConditionalExpr(expr *Conditional) (interface{}, error) {
+	return p.parenthesize("?:", expr.Condition, expr.ThenBranch, expr.ElseBranch), nil
+}
+
+// VisitOptionalChainExpr 链中的 ?. 已经体现在Get和Call中，这里直接打印整个链
+func (p *Printer) VisitOptionalChainExpr(expr *OptionalChain) (interface{}, error) {
+	return expr.Expression.Accept(p)
+}
+
+func (p *Printer) VisitBadExpr(expr *BadExpr) (interface{}, error) {
+	return "(bad " + expr.Pos().String() + "-" + expr.End().String() + ")", nil
+}
+
+// ################### Statement #####################
+
+func (p *Printer) VisitExprStmt(stmt *ExprStmt) error {
+	p.output = p.parenthesize(";", stmt.Expr)
+	return nil
+}
+
+func (p *Printer) VisitPrintStmt(stmt *PrintStmt) error {
+	p.output = p.parenthesize("print", stmt.Expr)
+	return nil
+}
+
+func (p *Printer) VisitVarDeclStmt(stmt *VarDeclStmt) error {
+	if stmt.Initializer == nil {
+		p.output = p.parenthesize("var", stmt.Name.Lexeme)
+	} else {
+		p.output = p.parenthesize("var", stmt.Name.Lexeme, stmt.Initializer)
+	}
+	return nil
+}
+
+func (p *Printer) VisitBlockStmt(stmt *BlockStmt) error {
+	p.output = p.parenthesize("block", stmts(stmt.Stmts)...)
+	return nil
+}
+
+func (p *Printer) VisitIfStmt(stmt *IfStmt) error {
+	if stmt.ElseBranch == nil {
+		p.output = p.parenthesize("if", stmt.Condition, stmt.ThenBranch)
+	} else {
+		p.output = p.parenthesize("if", stmt.Condition, stmt.ThenBranch, stmt.ElseBranch)
+	}
+	return nil
+}
+
+func (p *Printer) VisitWhileStmt(stmt *WhileStmt) error {
+	p.output = p.parenthesize("while", stmt.Condition, stmt.Body)
+	return nil
+}
+
+func (p *Printer) VisitForInStmt(stmt *ForInStmt) error {
+	p.output = p.parenthesize("for-in", stmt.Name.Lexeme, stmt.Iterable, stmt.Body)
+	return nil
+}
+
+func (p *Printer) VisitFuncDeclStmt(stmt *FuncDeclStmt) error {
+	p.output = p.function("fun "+stmt.Name.Lexeme, stmt)
+	return nil
+}
+
+func (p *Printer) VisitReturnStmt(stmt *ReturnStmt) error {
+	if stmt.Value == nil {
+		p.output = "(return)"
+	} else {
+		p.output = p.parenthesize("return", stmt.Value)
+	}
+	return nil
+}
+
+func (p *Printer) VisitYieldStmt(stmt *YieldStmt) error {
+	if stmt.Value == nil {
+		p.output = "(yield)"
+	} else {
+		p.output = p.parenthesize("yield", stmt.Value)
+	}
+	return nil
+}
+
+func (p *Printer) VisitClassDeclStmt(stmt *ClassDeclStmt) error {
+	parts := []interface{}{stmt.Name.Lexeme}
+	if stmt.Superclass != nil {
+		parts = append(parts, p.parenthesize("<", stmt.Superclass))
+	}
+	for _, trait := range stmt.Traits {
+		parts = append(parts, p.parenthesize("with", trait))
+	}
+	for _, field := range stmt.Fields {
+		parts = append(parts, field)
+	}
+	for _, method := range stmt.ClassMethods {
+		parts = append(parts, p.function("class "+method.Name.Lexeme, method))
+	}
+	for _, getter := range stmt.Getters {
+		parts = append(parts, p.function("get "+getter.Name.Lexeme, getter))
+	}
+	for _, setter := range stmt.Setters {
+		parts = append(parts, p.function("set "+setter.Name.Lexeme, setter))
+	}
+	for _, method := range stmt.Methods {
+		parts = append(parts, p.function(method.Name.Lexeme, method))
+	}
+
+	p.output = p.parenthesize("class", parts...)
+	return nil
+}
+
+func (p *Printer) VisitTraitDeclStmt(stmt *TraitDeclStmt) error {
+	parts := []interface{}{stmt.Name.Lexeme}
+	for _, method := range stmt.Methods {
+		parts = append(parts, p.function(method.Name.Lexeme, method))
+	}
+
+	p.output = p.parenthesize("trait", parts...)
+	return nil
+}
+
+func (p *Printer) VisitBadStmt(stmt *BadStmt) error {
+	p.output = "(bad " + stmt.Pos().String() + "-" + stmt.End().String() + ")"
+	return nil
+}
+
+// function 打印函数的参数列表和函数体，比如 (fun add (a b) (return (+ a b)))
+func (p *Printer) function(name string, function *FuncDeclStmt) string {
+	var params []string
+	for _, param := range function.Params {
+		params = append(params, param.Lexeme)
+	}
+
+	parts := []interface{}{"(" + strings.Join(params, " ") + ")"}
+	parts = append(parts, stmts(function.Body.Stmts)...)
+
+	return p.parenthesize(name, parts...)
+}
+
+// parenthesize 把name和parts用括号括起来，parts可以是Expr、Stmt或者已经打印好的字符串
+func (p *Printer) parenthesize(name string, parts ...interface{}) string {
 	var buffer bytes.Buffer
 	buffer.WriteString("(" + name)
-	for _, expr := range exprs {
+	for _, part := range parts {
 		buffer.WriteString(" ")
-		output, _ := expr.Accept(p)
-		buffer.WriteString(output.(string))
+		switch part := part.(type) {
+		case Expr:
+			buffer.WriteString(p.Print(part))
+		case Stmt:
+			buffer.WriteString(p.PrintStmt(part))
+		case string:
+			buffer.WriteString(part)
+		}
 	}
 	buffer.WriteString(")")
 
@@ -94,3 +268,37 @@ func (p *Printer) Print(expr Expr) string {
 	output, _ := expr.Accept(p)
 	return output.(string)
 }
+
+func (p *Printer) PrintStmt(stmt Stmt) string {
+	_ = stmt.Accept(p)
+	return p.output
+}
+
+// PrintProgram 每一行打印一个顶层的statement
+func (p *Printer) PrintProgram(program []Stmt) string {
+	var lines []string
+	for _, stmt := range program {
+		lines = append(lines, p.PrintStmt(stmt))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// assignOperator 复合赋值打印为原来的运算符，比如 +=
+// assign 按源代码中的运算符输出赋值，"++"和"--"的步长是隐含的，不输出
+func (p *Printer) assign(equals *token.Token, target interface{}, value Expr) string {
+	if equals.Type == token.PLUS_PLUS || equals.Type == token.MINUS_MINUS {
+		return p.parenthesize(equals.Lexeme, target)
+	}
+
+	return p.parenthesize(equals.Lexeme, target, value)
+}
+
+func stmts(stmts []Stmt) []interface{} {
+	parts := make([]interface{}, len(stmts))
+	for i, stmt := range stmts {
+		parts[i] = stmt
+	}
+
+	return parts
+}
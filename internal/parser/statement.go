@@ -1,3 +1,5 @@
+// Code generated by tools/ast from ast.schema; DO NOT EDIT.
+
 package parser
 
 import (
@@ -5,7 +7,10 @@ import (
 )
 
 type Stmt interface {
+	Node
 	Accept(visitor StmtVisitor) error
+	// Line 返回statement开始的行号，无法确定的时候返回0，见position.go
+	Line() int
 }
 
 type ExprStmt struct {
@@ -13,45 +18,104 @@ type ExprStmt struct {
 }
 
 func NewExprStmt(expr Expr) *ExprStmt {
-	return &ExprStmt{expr}
+	return &ExprStmt{Expr: expr}
+}
+
+func (e *ExprStmt) Pos() token.Position {
+	pos, _ := span(e)
+	return pos
+}
+
+func (e *ExprStmt) End() token.Position {
+	_, end := span(e)
+	return end
 }
 
 func (e *ExprStmt) Accept(visitor StmtVisitor) error {
 	return visitor.VisitExprStmt(e)
 }
 
+// FuncDeclStmt 中Keyword是"fun"、静态方法的"class"或者setter的"set"，普通的方法、getter和箭头函数没有Keyword。
+// Generator表示函数体中有yield，在构造时确定，-O删除了yield所在的代码之后函数仍然是生成器
 type FuncDeclStmt struct {
-	Name   *token.Token
-	Params []*token.Token
-	Body   *BlockStmt
+	Keyword   *token.Token
+	Name      *token.Token
+	Params    []*token.Token
+	Body      *BlockStmt
+	Generator bool
 }
 
-func NewFunctionStmt(name *token.Token, params []*token.Token, body *BlockStmt) *FuncDeclStmt {
-	return &FuncDeclStmt{Name: name, Params: params, Body: body}
+func (f *FuncDeclStmt) Pos() token.Position {
+	pos, _ := span(f)
+	return pos
+}
+
+func (f *FuncDeclStmt) End() token.Position {
+	_, end := span(f)
+	return end
 }
 
 func (f *FuncDeclStmt) Accept(visitor StmtVisitor) error {
 	return visitor.VisitFuncDeclStmt(f)
 }
 
+// ClassDeclStmt 中ClassMethods是用"class"修饰的静态方法，Getters没有参数列表，Fields是类级别的常量，
+// Traits是通过"with"混入的trait
 type ClassDeclStmt struct {
-	Name       *token.Token
-	Superclass *Variable
-	Methods    []*FuncDeclStmt
+	Keyword      *token.Token
+	Name         *token.Token
+	Superclass   *Variable
+	Traits       []*Variable
+	Methods      []*FuncDeclStmt
+	ClassMethods []*FuncDeclStmt
+	Getters      []*FuncDeclStmt
+	Setters      []*FuncDeclStmt
+	Fields       []*VarDeclStmt
+}
+
+func NewClassDeclStmt(keyword *token.Token, name *token.Token, superclass *Variable, traits []*Variable, methods []*FuncDeclStmt, classMethods []*FuncDeclStmt, getters []*FuncDeclStmt, setters []*FuncDeclStmt, fields []*VarDeclStmt) *ClassDeclStmt {
+	return &ClassDeclStmt{Keyword: keyword, Name: name, Superclass: superclass, Traits: traits, Methods: methods, ClassMethods: classMethods, Getters: getters, Setters: setters, Fields: fields}
+}
+
+func (c *ClassDeclStmt) Pos() token.Position {
+	pos, _ := span(c)
+	return pos
 }
 
-func NewClassDeclStmt(name *token.Token, superclass *Variable, methods []*FuncDeclStmt) *ClassDeclStmt {
-	return &ClassDeclStmt{
-		Name:       name,
-		Superclass: superclass,
-		Methods:    methods,
-	}
+func (c *ClassDeclStmt) End() token.Position {
+	_, end := span(c)
+	return end
 }
 
 func (c *ClassDeclStmt) Accept(visitor StmtVisitor) error {
 	return visitor.VisitClassDeclStmt(c)
 }
 
+// TraitDeclStmt trait中的方法会被复制到混入它的类中
+type TraitDeclStmt struct {
+	Keyword *token.Token
+	Name    *token.Token
+	Methods []*FuncDeclStmt
+}
+
+func NewTraitDeclStmt(keyword *token.Token, name *token.Token, methods []*FuncDeclStmt) *TraitDeclStmt {
+	return &TraitDeclStmt{Keyword: keyword, Name: name, Methods: methods}
+}
+
+func (t *TraitDeclStmt) Pos() token.Position {
+	pos, _ := span(t)
+	return pos
+}
+
+func (t *TraitDeclStmt) End() token.Position {
+	_, end := span(t)
+	return end
+}
+
+func (t *TraitDeclStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitTraitDeclStmt(t)
+}
+
 type ReturnStmt struct {
 	Keyword *token.Token
 	Value   Expr
@@ -61,16 +125,61 @@ func NewReturnStmt(keyword *token.Token, value Expr) *ReturnStmt {
 	return &ReturnStmt{Keyword: keyword, Value: value}
 }
 
+func (r *ReturnStmt) Pos() token.Position {
+	pos, _ := span(r)
+	return pos
+}
+
+func (r *ReturnStmt) End() token.Position {
+	_, end := span(r)
+	return end
+}
+
 func (r *ReturnStmt) Accept(visitor StmtVisitor) error {
 	return visitor.VisitReturnStmt(r)
 }
 
+// YieldStmt 只能出现在函数中，含有yield的函数被调用时返回一个generator，见FuncDeclStmt.Generator
+type YieldStmt struct {
+	Keyword *token.Token
+	Value   Expr
+}
+
+func NewYieldStmt(keyword *token.Token, value Expr) *YieldStmt {
+	return &YieldStmt{Keyword: keyword, Value: value}
+}
+
+func (y *YieldStmt) Pos() token.Position {
+	pos, _ := span(y)
+	return pos
+}
+
+func (y *YieldStmt) End() token.Position {
+	_, end := span(y)
+	return end
+}
+
+func (y *YieldStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitYieldStmt(y)
+}
+
 type PrintStmt struct {
-	Expr Expr
+	Keyword *token.Token
+	Expr    Expr
 }
 
-func NewPrintStmt(expr Expr) *PrintStmt {
-	return &PrintStmt{expr}
+func NewPrintStmt(keyword *token.Token, expr Expr) *PrintStmt {
+	return &PrintStmt{Keyword: keyword, Expr: expr}
+}
+
+func (p *PrintStmt) Pos() token.Position {
+	pos, _ := span(p)
+	return pos
+}
+
+func (p *PrintStmt) End() token.Position {
+	_, end := span(p)
+	return end
 }
 
 func (p *PrintStmt) Accept(visitor StmtVisitor) error {
@@ -78,12 +187,23 @@ func (p *PrintStmt) Accept(visitor StmtVisitor) error {
 }
 
 type VarDeclStmt struct {
+	Keyword     *token.Token
 	Name        *token.Token
 	Initializer Expr
 }
 
-func NewVarDeclStmt(name *token.Token, initializer Expr) *VarDeclStmt {
-	return &VarDeclStmt{Name: name, Initializer: initializer}
+func NewVarDeclStmt(keyword *token.Token, name *token.Token, initializer Expr) *VarDeclStmt {
+	return &VarDeclStmt{Keyword: keyword, Name: name, Initializer: initializer}
+}
+
+func (v *VarDeclStmt) Pos() token.Position {
+	pos, _ := span(v)
+	return pos
+}
+
+func (v *VarDeclStmt) End() token.Position {
+	_, end := span(v)
+	return end
 }
 
 func (v *VarDeclStmt) Accept(visitor StmtVisitor) error {
@@ -99,33 +219,116 @@ func NewBlockStmt(stmts []Stmt) *BlockStmt {
 	return &BlockStmt{Stmts: stmts}
 }
 
+func (b *BlockStmt) Pos() token.Position {
+	pos, _ := span(b)
+	return pos
+}
+
+func (b *BlockStmt) End() token.Position {
+	_, end := span(b)
+	return end
+}
+
 func (b *BlockStmt) Accept(visitor StmtVisitor) error {
 	return visitor.VisitBlockStmt(b)
 }
 
 type IfStmt struct {
+	Keyword    *token.Token
 	Condition  Expr
 	ThenBranch Stmt
 	ElseBranch Stmt
 }
 
-func NewIfStmt(condition Expr, trueBranch Stmt, elseBranch Stmt) *IfStmt {
-	return &IfStmt{Condition: condition, ThenBranch: trueBranch, ElseBranch: elseBranch}
+func NewIfStmt(keyword *token.Token, condition Expr, thenBranch Stmt, elseBranch Stmt) *IfStmt {
+	return &IfStmt{Keyword: keyword, Condition: condition, ThenBranch: thenBranch, ElseBranch: elseBranch}
+}
+
+func (i *IfStmt) Pos() token.Position {
+	pos, _ := span(i)
+	return pos
+}
+
+func (i *IfStmt) End() token.Position {
+	_, end := span(i)
+	return end
 }
 
 func (i *IfStmt) Accept(visitor StmtVisitor) error {
 	return visitor.VisitIfStmt(i)
 }
 
+// WhileStmt 中Keyword是"while"或者脱糖之前的"for"
 type WhileStmt struct {
+	Keyword   *token.Token
 	Condition Expr
 	Body      Stmt
 }
 
-func NewWhileStmt(condition Expr, body Stmt) *WhileStmt {
-	return &WhileStmt{Condition: condition, Body: body}
+func NewWhileStmt(keyword *token.Token, condition Expr, body Stmt) *WhileStmt {
+	return &WhileStmt{Keyword: keyword, Condition: condition, Body: body}
+}
+
+func (w *WhileStmt) Pos() token.Position {
+	pos, _ := span(w)
+	return pos
+}
+
+func (w *WhileStmt) End() token.Position {
+	_, end := span(w)
+	return end
 }
 
 func (w *WhileStmt) Accept(visitor StmtVisitor) error {
 	return visitor.VisitWhileStmt(w)
 }
+
+// ForInStmt 遍历一个可迭代的值，如 for (x in xs) print x; 每一轮循环中Name都绑定在一个新的作用域里
+type ForInStmt struct {
+	Keyword  *token.Token
+	Name     *token.Token
+	Iterable Expr
+	Body     Stmt
+}
+
+func NewForInStmt(keyword *token.Token, name *token.Token, iterable Expr, body Stmt) *ForInStmt {
+	return &ForInStmt{Keyword: keyword, Name: name, Iterable: iterable, Body: body}
+}
+
+func (f *ForInStmt) Pos() token.Position {
+	pos, _ := span(f)
+	return pos
+}
+
+func (f *ForInStmt) End() token.Position {
+	_, end := span(f)
+	return end
+}
+
+func (f *ForInStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitForInStmt(f)
+}
+
+// BadStmt 容错解析时代替有语法错误的statement，From和To是它覆盖的第一个和最后一个token
+type BadStmt struct {
+	From *token.Token
+	To   *token.Token
+}
+
+func NewBadStmt(from *token.Token, to *token.Token) *BadStmt {
+	return &BadStmt{From: from, To: to}
+}
+
+func (b *BadStmt) Pos() token.Position {
+	pos, _ := span(b)
+	return pos
+}
+
+func (b *BadStmt) End() token.Position {
+	_, end := span(b)
+	return end
+}
+
+func (b *BadStmt) Accept(visitor StmtVisitor) error {
+	return visitor.VisitBadStmt(b)
+}
@@ -0,0 +1,627 @@
+// Code generated by tools/ast from ast.schema; DO NOT EDIT.
+
+package parser
+
+import (
+	"GLox/internal/scanner/token"
+)
+
+// WalkChildren 按字段定义的顺序对node的每个非nil子节点调用fn，不会递归
+func WalkChildren(node Node, fn func(child Node)) {
+	switch n := node.(type) {
+	case *Binary:
+		if n.Left != nil {
+			fn(n.Left)
+		}
+		if n.Right != nil {
+			fn(n.Right)
+		}
+	case *Grouping:
+		if n.Expression != nil {
+			fn(n.Expression)
+		}
+	case *Unary:
+		if n.Right != nil {
+			fn(n.Right)
+		}
+	case *Assign:
+		if n.Value != nil {
+			fn(n.Value)
+		}
+	case *Logic:
+		if n.Left != nil {
+			fn(n.Left)
+		}
+		if n.Right != nil {
+			fn(n.Right)
+		}
+	case *Call:
+		if n.Callee != nil {
+			fn(n.Callee)
+		}
+		for _, child := range n.Arguments {
+			if child != nil {
+				fn(child)
+			}
+		}
+	case *Get:
+		if n.Object != nil {
+			fn(n.Object)
+		}
+	case *Set:
+		if n.Object != nil {
+			fn(n.Object)
+		}
+		if n.Value != nil {
+			fn(n.Value)
+		}
+	case *Index:
+		if n.Object != nil {
+			fn(n.Object)
+		}
+		if n.Index != nil {
+			fn(n.Index)
+		}
+	case *IndexSet:
+		if n.Object != nil {
+			fn(n.Object)
+		}
+		if n.Index != nil {
+			fn(n.Index)
+		}
+		if n.Value != nil {
+			fn(n.Value)
+		}
+	case *Postfix:
+		if n.Target != nil {
+			fn(n.Target)
+		}
+	case *Lambda:
+		if n.Function != nil {
+			fn(n.Function)
+		}
+	case *List:
+		for _, child := range n.Elements {
+			if child != nil {
+				fn(child)
+			}
+		}
+	case *Map:
+		for _, child := range n.Keys {
+			if child != nil {
+				fn(child)
+			}
+		}
+		for _, child := range n.Values {
+			if child != nil {
+				fn(child)
+			}
+		}
+	case *Conditional:
+		if n.Condition != nil {
+			fn(n.Condition)
+		}
+		if n.ThenBranch != nil {
+			fn(n.ThenBranch)
+		}
+		if n.ElseBranch != nil {
+			fn(n.ElseBranch)
+		}
+	case *OptionalChain:
+		if n.Expression != nil {
+			fn(n.Expression)
+		}
+	case *ExprStmt:
+		if n.Expr != nil {
+			fn(n.Expr)
+		}
+	case *FuncDeclStmt:
+		if n.Body != nil {
+			fn(n.Body)
+		}
+	case *ClassDeclStmt:
+		if n.Superclass != nil {
+			fn(n.Superclass)
+		}
+		for _, child := range n.Traits {
+			if child != nil {
+				fn(child)
+			}
+		}
+		for _, child := range n.Methods {
+			if child != nil {
+				fn(child)
+			}
+		}
+		for _, child := range n.ClassMethods {
+			if child != nil {
+				fn(child)
+			}
+		}
+		for _, child := range n.Getters {
+			if child != nil {
+				fn(child)
+			}
+		}
+		for _, child := range n.Setters {
+			if child != nil {
+				fn(child)
+			}
+		}
+		for _, child := range n.Fields {
+			if child != nil {
+				fn(child)
+			}
+		}
+	case *TraitDeclStmt:
+		for _, child := range n.Methods {
+			if child != nil {
+				fn(child)
+			}
+		}
+	case *ReturnStmt:
+		if n.Value != nil {
+			fn(n.Value)
+		}
+	case *YieldStmt:
+		if n.Value != nil {
+			fn(n.Value)
+		}
+	case *PrintStmt:
+		if n.Expr != nil {
+			fn(n.Expr)
+		}
+	case *VarDeclStmt:
+		if n.Initializer != nil {
+			fn(n.Initializer)
+		}
+	case *BlockStmt:
+		for _, child := range n.Stmts {
+			if child != nil {
+				fn(child)
+			}
+		}
+	case *IfStmt:
+		if n.Condition != nil {
+			fn(n.Condition)
+		}
+		if n.ThenBranch != nil {
+			fn(n.ThenBranch)
+		}
+		if n.ElseBranch != nil {
+			fn(n.ElseBranch)
+		}
+	case *WhileStmt:
+		if n.Condition != nil {
+			fn(n.Condition)
+		}
+		if n.Body != nil {
+			fn(n.Body)
+		}
+	case *ForInStmt:
+		if n.Iterable != nil {
+			fn(n.Iterable)
+		}
+		if n.Body != nil {
+			fn(n.Body)
+		}
+	}
+}
+
+// RewriteChildren 用fn的返回值替换node的每个非nil子节点。fn返回nil（或者类型不符合字段的类型）时，
+// 列表中的子节点会被删除，单个的子节点会被置为nil
+func RewriteChildren(node Node, fn func(child Node) Node) {
+	switch n := node.(type) {
+	case *Binary:
+		if n.Left != nil {
+			n.Left, _ = fn(n.Left).(Expr)
+		}
+		if n.Right != nil {
+			n.Right, _ = fn(n.Right).(Expr)
+		}
+	case *Grouping:
+		if n.Expression != nil {
+			n.Expression, _ = fn(n.Expression).(Expr)
+		}
+	case *Unary:
+		if n.Right != nil {
+			n.Right, _ = fn(n.Right).(Expr)
+		}
+	case *Assign:
+		if n.Value != nil {
+			n.Value, _ = fn(n.Value).(Expr)
+		}
+	case *Logic:
+		if n.Left != nil {
+			n.Left, _ = fn(n.Left).(Expr)
+		}
+		if n.Right != nil {
+			n.Right, _ = fn(n.Right).(Expr)
+		}
+	case *Call:
+		if n.Callee != nil {
+			n.Callee, _ = fn(n.Callee).(Expr)
+		}
+		arguments := n.Arguments[:0]
+		for _, child := range n.Arguments {
+			if child == nil {
+				arguments = append(arguments, child)
+			} else if child, ok := fn(child).(Expr); ok && child != nil {
+				arguments = append(arguments, child)
+			}
+		}
+		n.Arguments = arguments
+	case *Get:
+		if n.Object != nil {
+			n.Object, _ = fn(n.Object).(Expr)
+		}
+	case *Set:
+		if n.Object != nil {
+			n.Object, _ = fn(n.Object).(Expr)
+		}
+		if n.Value != nil {
+			n.Value, _ = fn(n.Value).(Expr)
+		}
+	case *Index:
+		if n.Object != nil {
+			n.Object, _ = fn(n.Object).(Expr)
+		}
+		if n.Index != nil {
+			n.Index, _ = fn(n.Index).(Expr)
+		}
+	case *IndexSet:
+		if n.Object != nil {
+			n.Object, _ = fn(n.Object).(Expr)
+		}
+		if n.Index != nil {
+			n.Index, _ = fn(n.Index).(Expr)
+		}
+		if n.Value != nil {
+			n.Value, _ = fn(n.Value).(Expr)
+		}
+	case *Postfix:
+		if n.Target != nil {
+			n.Target, _ = fn(n.Target).(Expr)
+		}
+	case *Lambda:
+		if n.Function != nil {
+			n.Function, _ = fn(n.Function).(*FuncDeclStmt)
+		}
+	case *List:
+		elements := n.Elements[:0]
+		for _, child := range n.Elements {
+			if child == nil {
+				elements = append(elements, child)
+			} else if child, ok := fn(child).(Expr); ok && child != nil {
+				elements = append(elements, child)
+			}
+		}
+		n.Elements = elements
+	case *Map:
+		keys := n.Keys[:0]
+		for _, child := range n.Keys {
+			if child == nil {
+				keys = append(keys, child)
+			} else if child, ok := fn(child).(Expr); ok && child != nil {
+				keys = append(keys, child)
+			}
+		}
+		n.Keys = keys
+		values := n.Values[:0]
+		for _, child := range n.Values {
+			if child == nil {
+				values = append(values, child)
+			} else if child, ok := fn(child).(Expr); ok && child != nil {
+				values = append(values, child)
+			}
+		}
+		n.Values = values
+	case *Conditional:
+		if n.Condition != nil {
+			n.Condition, _ = fn(n.Condition).(Expr)
+		}
+		if n.ThenBranch != nil {
+			n.ThenBranch, _ = fn(n.ThenBranch).(Expr)
+		}
+		if n.ElseBranch != nil {
+			n.ElseBranch, _ = fn(n.ElseBranch).(Expr)
+		}
+	case *OptionalChain:
+		if n.Expression != nil {
+			n.Expression, _ = fn(n.Expression).(Expr)
+		}
+	case *ExprStmt:
+		if n.Expr != nil {
+			n.Expr, _ = fn(n.Expr).(Expr)
+		}
+	case *FuncDeclStmt:
+		if n.Body != nil {
+			n.Body, _ = fn(n.Body).(*BlockStmt)
+		}
+	case *ClassDeclStmt:
+		if n.Superclass != nil {
+			n.Superclass, _ = fn(n.Superclass).(*Variable)
+		}
+		traits := n.Traits[:0]
+		for _, child := range n.Traits {
+			if child == nil {
+				traits = append(traits, child)
+			} else if child, ok := fn(child).(*Variable); ok && child != nil {
+				traits = append(traits, child)
+			}
+		}
+		n.Traits = traits
+		methods := n.Methods[:0]
+		for _, child := range n.Methods {
+			if child == nil {
+				methods = append(methods, child)
+			} else if child, ok := fn(child).(*FuncDeclStmt); ok && child != nil {
+				methods = append(methods, child)
+			}
+		}
+		n.Methods = methods
+		classMethods := n.ClassMethods[:0]
+		for _, child := range n.ClassMethods {
+			if child == nil {
+				classMethods = append(classMethods, child)
+			} else if child, ok := fn(child).(*FuncDeclStmt); ok && child != nil {
+				classMethods = append(classMethods, child)
+			}
+		}
+		n.ClassMethods = classMethods
+		getters := n.Getters[:0]
+		for _, child := range n.Getters {
+			if child == nil {
+				getters = append(getters, child)
+			} else if child, ok := fn(child).(*FuncDeclStmt); ok && child != nil {
+				getters = append(getters, child)
+			}
+		}
+		n.Getters = getters
+		setters := n.Setters[:0]
+		for _, child := range n.Setters {
+			if child == nil {
+				setters = append(setters, child)
+			} else if child, ok := fn(child).(*FuncDeclStmt); ok && child != nil {
+				setters = append(setters, child)
+			}
+		}
+		n.Setters = setters
+		fields := n.Fields[:0]
+		for _, child := range n.Fields {
+			if child == nil {
+				fields = append(fields, child)
+			} else if child, ok := fn(child).(*VarDeclStmt); ok && child != nil {
+				fields = append(fields, child)
+			}
+		}
+		n.Fields = fields
+	case *TraitDeclStmt:
+		methods := n.Methods[:0]
+		for _, child := range n.Methods {
+			if child == nil {
+				methods = append(methods, child)
+			} else if child, ok := fn(child).(*FuncDeclStmt); ok && child != nil {
+				methods = append(methods, child)
+			}
+		}
+		n.Methods = methods
+	case *ReturnStmt:
+		if n.Value != nil {
+			n.Value, _ = fn(n.Value).(Expr)
+		}
+	case *YieldStmt:
+		if n.Value != nil {
+			n.Value, _ = fn(n.Value).(Expr)
+		}
+	case *PrintStmt:
+		if n.Expr != nil {
+			n.Expr, _ = fn(n.Expr).(Expr)
+		}
+	case *VarDeclStmt:
+		if n.Initializer != nil {
+			n.Initializer, _ = fn(n.Initializer).(Expr)
+		}
+	case *BlockStmt:
+		stmts := n.Stmts[:0]
+		for _, child := range n.Stmts {
+			if child == nil {
+				stmts = append(stmts, child)
+			} else if child, ok := fn(child).(Stmt); ok && child != nil {
+				stmts = append(stmts, child)
+			}
+		}
+		n.Stmts = stmts
+	case *IfStmt:
+		if n.Condition != nil {
+			n.Condition, _ = fn(n.Condition).(Expr)
+		}
+		if n.ThenBranch != nil {
+			n.ThenBranch, _ = fn(n.ThenBranch).(Stmt)
+		}
+		if n.ElseBranch != nil {
+			n.ElseBranch, _ = fn(n.ElseBranch).(Stmt)
+		}
+	case *WhileStmt:
+		if n.Condition != nil {
+			n.Condition, _ = fn(n.Condition).(Expr)
+		}
+		if n.Body != nil {
+			n.Body, _ = fn(n.Body).(Stmt)
+		}
+	case *ForInStmt:
+		if n.Iterable != nil {
+			n.Iterable, _ = fn(n.Iterable).(Expr)
+		}
+		if n.Body != nil {
+			n.Body, _ = fn(n.Body).(Stmt)
+		}
+	}
+}
+
+// walkTokens 按字段定义的顺序对node中每个非nil的token调用fn，不包括子节点中的token
+func walkTokens(node Node, fn func(t *token.Token)) {
+	switch n := node.(type) {
+	case *Binary:
+		if n.Operator != nil {
+			fn(n.Operator)
+		}
+	case *Literal:
+		if n.Token != nil {
+			fn(n.Token)
+		}
+	case *Unary:
+		if n.Operator != nil {
+			fn(n.Operator)
+		}
+	case *Variable:
+		if n.Name != nil {
+			fn(n.Name)
+		}
+	case *Assign:
+		if n.Name != nil {
+			fn(n.Name)
+		}
+		if n.Equals != nil {
+			fn(n.Equals)
+		}
+		if n.Operator != nil {
+			fn(n.Operator)
+		}
+	case *Logic:
+		if n.Operator != nil {
+			fn(n.Operator)
+		}
+	case *Call:
+		if n.Paren != nil {
+			fn(n.Paren)
+		}
+	case *Get:
+		if n.Attribute != nil {
+			fn(n.Attribute)
+		}
+	case *Set:
+		if n.Attribute != nil {
+			fn(n.Attribute)
+		}
+		if n.Equals != nil {
+			fn(n.Equals)
+		}
+		if n.Operator != nil {
+			fn(n.Operator)
+		}
+	case *This:
+		if n.Keyword != nil {
+			fn(n.Keyword)
+		}
+	case *Super:
+		if n.Keyword != nil {
+			fn(n.Keyword)
+		}
+		if n.Identifier != nil {
+			fn(n.Identifier)
+		}
+	case *Index:
+		if n.Bracket != nil {
+			fn(n.Bracket)
+		}
+	case *IndexSet:
+		if n.Bracket != nil {
+			fn(n.Bracket)
+		}
+		if n.Equals != nil {
+			fn(n.Equals)
+		}
+		if n.Operator != nil {
+			fn(n.Operator)
+		}
+	case *Lambda:
+		if n.Keyword != nil {
+			fn(n.Keyword)
+		}
+	case *List:
+		if n.Bracket != nil {
+			fn(n.Bracket)
+		}
+	case *Map:
+		if n.Brace != nil {
+			fn(n.Brace)
+		}
+	case *Conditional:
+		if n.Question != nil {
+			fn(n.Question)
+		}
+	case *BadExpr:
+		if n.From != nil {
+			fn(n.From)
+		}
+		if n.To != nil {
+			fn(n.To)
+		}
+	case *FuncDeclStmt:
+		if n.Keyword != nil {
+			fn(n.Keyword)
+		}
+		if n.Name != nil {
+			fn(n.Name)
+		}
+		for _, t := range n.Params {
+			fn(t)
+		}
+	case *ClassDeclStmt:
+		if n.Keyword != nil {
+			fn(n.Keyword)
+		}
+		if n.Name != nil {
+			fn(n.Name)
+		}
+	case *TraitDeclStmt:
+		if n.Keyword != nil {
+			fn(n.Keyword)
+		}
+		if n.Name != nil {
+			fn(n.Name)
+		}
+	case *ReturnStmt:
+		if n.Keyword != nil {
+			fn(n.Keyword)
+		}
+	case *YieldStmt:
+		if n.Keyword != nil {
+			fn(n.Keyword)
+		}
+	case *PrintStmt:
+		if n.Keyword != nil {
+			fn(n.Keyword)
+		}
+	case *VarDeclStmt:
+		if n.Keyword != nil {
+			fn(n.Keyword)
+		}
+		if n.Name != nil {
+			fn(n.Name)
+		}
+	case *IfStmt:
+		if n.Keyword != nil {
+			fn(n.Keyword)
+		}
+	case *WhileStmt:
+		if n.Keyword != nil {
+			fn(n.Keyword)
+		}
+	case *ForInStmt:
+		if n.Keyword != nil {
+			fn(n.Keyword)
+		}
+		if n.Name != nil {
+			fn(n.Name)
+		}
+	case *BadStmt:
+		if n.From != nil {
+			fn(n.From)
+		}
+		if n.To != nil {
+			fn(n.To)
+		}
+	}
+}
@@ -11,4 +11,6 @@ const (
 	Method
 	InClass
 	SubClass
+	InStatic // 静态方法和类级别常量的初始化表达式中没有"this"
+	InTrait
 )
@@ -25,7 +25,7 @@ func (r *Resolver) VisitLiteralExpr(expr *parser.Literal) (interface{}, error) {
 }
 
 func (r *Resolver) VisitUnaryExpr(expr *parser.Unary) (interface{}, error) {
-	// empty implementation
+	r.resolveExpr(expr.Right)
 
 	return nil, nil
 }
@@ -87,17 +87,25 @@ func (r *Resolver) VisitSetExpr(expr *parser.Set) (interface{}, error) {
 
 // VisitThisExpr : if "this" does not appear in a method, report an error.
 func (r *Resolver) VisitThisExpr(expr *parser.This) (interface{}, error) {
-	if !(currentClass == InClass || currentClass == SubClass) {
+	if currentClass == InStatic {
+		le.ReportResolveError(expr.Keyword, "Can't use 'this' in a static context.")
+	} else if !(currentClass == InClass || currentClass == SubClass || currentClass == InTrait) {
 		//panic(le.NewRuntimeError(expr.Keyword, "Can't use 'this' outside of a class."))
 		le.ReportResolveError(expr.Keyword, "Can't use 'this' outside of a class.")
 	}
 
+	r.resolveLocal(expr, expr.Keyword)
+
 	return nil, nil
 }
 
 func (r *Resolver) VisitSuperExpr(expr *parser.Super) (interface{}, error) {
 	if currentClass == None {
 		le.ReportResolveError(expr.Keyword, "Can't use 'super' outside of a class.")
+	} else if currentClass == InStatic {
+		le.ReportResolveError(expr.Keyword, "Can't use 'super' in a static context.")
+	} else if currentClass == InTrait {
+		le.ReportResolveError(expr.Keyword, "Can't use 'super' in a trait.")
 	} else if currentClass != SubClass {
 		le.ReportResolveError(expr.Keyword, "Can't use 'super' in a class without superclass.")
 	}
@@ -106,3 +114,66 @@ func (r *Resolver) VisitSuperExpr(expr *parser.Super) (interface{}, error) {
 
 	return nil, nil
 }
+
+func (r *Resolver) VisitIndexExpr(expr *parser.Index) (interface{}, error) {
+	r.resolveExpr(expr.Object)
+	r.resolveExpr(expr.Index)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndexSetExpr(expr *parser.IndexSet) (interface{}, error) {
+	r.resolveExpr(expr.Object)
+	r.resolveExpr(expr.Index)
+	r.resolveExpr(expr.Value)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitPostfixExpr(expr *parser.Postfix) (interface{}, error) {
+	r.resolveExpr(expr.Target)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitLambdaExpr(expr *parser.Lambda) (interface{}, error) {
+	r.resolveFunction(expr.Function, Function)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitListExpr(expr *parser.List) (interface{}, error) {
+	for _, element := range expr.Elements {
+		r.resolveExpr(element)
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitMapExpr(expr *parser.Map) (interface{}, error) {
+	for i, key := range expr.Keys {
+		r.resolveExpr(key)
+		r.resolveExpr(expr.Values[i])
+	}
+
+	return nil, nil
+}
+
+// VisitConditionalExpr 和Logic一样，运行时只会执行一个分支，但是两个分支都要resolve
+func (r *Resolver) VisitConditionalExpr(expr *parser.Conditional) (interface{}, error) {
+	r.resolveExpr(expr.Condition)
+	r.resolveExpr(expr.ThenBranch)
+	r.resolveExpr(expr.ElseBranch)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitOptionalChainExpr(expr *parser.OptionalChain) (interface{}, error) {
+	r.resolveExpr(expr.Expression)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitBadExpr(expr *parser.BadExpr) (interface{}, error) {
+	return nil, nil
+}
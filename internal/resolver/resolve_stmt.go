@@ -47,6 +47,18 @@ func (r *Resolver) VisitWhileStmt(stmt *parser.WhileStmt) error {
 	return nil
 }
 
+// VisitForInStmt 循环变量位于一个单独的作用域中，iterable在循环外面求值，看不到循环变量
+func (r *Resolver) VisitForInStmt(stmt *parser.ForInStmt) error {
+	r.resolveExpr(stmt.Iterable)
+
+	r.beginScope()
+	r.declare(stmt.Name)
+	r.define(stmt.Name)
+	r.ResolveStmt(stmt.Body)
+	r.endScope()
+	return nil
+}
+
 func (r *Resolver) VisitFuncDeclStmt(stmt *parser.FuncDeclStmt) error {
 	r.declare(stmt.Name)
 	r.define(stmt.Name)
@@ -59,19 +71,42 @@ func (r *Resolver) VisitReturnStmt(stmt *parser.ReturnStmt) error {
 	if stmt.Value != nil {
 		if currentCallable == Initializer {
 			le.ReportResolveError(stmt.Keyword, "Can't return a value from initializer.")
+		} else if inGenerator {
+			le.ReportResolveError(stmt.Keyword, "Can't return a value from a generator.")
 		}
 		r.resolveExpr(stmt.Value)
 	}
 	return nil
 }
 
+func (r *Resolver) VisitYieldStmt(stmt *parser.YieldStmt) error {
+	if currentCallable == None {
+		le.ReportResolveError(stmt.Keyword, "Can't use 'yield' outside of a function.")
+	} else if currentCallable == Initializer {
+		le.ReportResolveError(stmt.Keyword, "Can't yield from an initializer.")
+	}
+	if stmt.Value != nil {
+		r.resolveExpr(stmt.Value)
+	}
+	return nil
+}
+
 func (r *Resolver) VisitClassDeclStmt(stmt *parser.ClassDeclStmt) error {
 	var enclosingClass = currentClass
-	currentClass = InClass
 
 	// Lox允许将一个类声明为局部变量
 	r.declare(stmt.Name)
 	r.define(stmt.Name)
+
+	// 类级别的常量在类声明所在的作用域中求值
+	currentClass = InStatic
+	for _, field := range stmt.Fields {
+		if field.Initializer != nil {
+			r.resolveExpr(field.Initializer)
+		}
+	}
+	currentClass = InClass
+
 	//if stmt.Superclass != nil && stmt.Superclass.Name.Lexeme == stmt.Name.Lexeme {
 	//	panic(le.NewRuntimeError(stmt.Superclass.Name, "A class can't inherit from itself."))
 	//}
@@ -86,6 +121,19 @@ func (r *Resolver) VisitClassDeclStmt(stmt *parser.ClassDeclStmt) error {
 		}
 	}
 
+	for _, trait := range stmt.Traits {
+		r.resolveExpr(trait)
+	}
+	r.checkTraitConflicts(stmt)
+
+	// 静态方法不会绑定"this"，所以位于"this"的作用域之外
+	classType := currentClass
+	currentClass = InStatic
+	for _, method := range stmt.ClassMethods {
+		r.resolveFunction(method, Method)
+	}
+	currentClass = classType
+
 	// 处理特殊的变量"this"，为它创建一个单独的作用域，位于类中方法的上层
 	r.beginScope()
 	r.scopes.Peek().(Scope)["this"] = true
@@ -94,12 +142,64 @@ func (r *Resolver) VisitClassDeclStmt(stmt *parser.ClassDeclStmt) error {
 		callableType := utils.Ternary[CallableType](method.Name.Lexeme == "init", Initializer, Method)
 		r.resolveFunction(method, callableType)
 	}
-	r.endScope() // 对应81行开启的"this"的作用域
+	for _, getter := range stmt.Getters {
+		r.resolveFunction(getter, Method)
+	}
+	for _, setter := range stmt.Setters {
+		r.resolveFunction(setter, Method)
+	}
+	r.endScope() // 对应"this"的作用域
 
-	if stmt.Superclass != nil {
-		r.endScope() // 对应75行开启的"super"的作用域
+	if stmt.Superclass != nil && stmt.Superclass.Name.Lexeme != stmt.Name.Lexeme {
+		r.endScope() // 对应"super"的作用域
 	}
 
 	currentClass = enclosingClass
 	return nil
 }
+
+func (r *Resolver) VisitTraitDeclStmt(stmt *parser.TraitDeclStmt) error {
+	var enclosingClass = currentClass
+	currentClass = InTrait
+
+	r.declare(stmt.Name)
+	r.define(stmt.Name)
+	r.declareTrait(stmt)
+
+	// trait中的方法和类中的方法一样，"this"的作用域位于方法的上层
+	r.beginScope()
+	r.scopes.Peek().(Scope)["this"] = true
+	for _, method := range stmt.Methods {
+		if method.Name.Lexeme == "init" {
+			le.ReportResolveError(method.Name, "A trait can't define an initializer.")
+		}
+		r.resolveFunction(method, Method)
+	}
+	r.endScope()
+
+	currentClass = enclosingClass
+	return nil
+}
+
+func (r *Resolver) VisitBadStmt(stmt *parser.BadStmt) error {
+	return nil
+}
+
+// checkTraitConflicts 如果多个trait提供了同名的方法，类必须自己重新定义这个方法，否则无法确定使用哪个
+func (r *Resolver) checkTraitConflicts(stmt *parser.ClassDeclStmt) {
+	provider := make(map[string]string)
+	for _, variable := range stmt.Traits {
+		trait := r.lookupTrait(variable.Name.Lexeme)
+		if trait == nil {
+			continue
+		}
+
+		for _, method := range trait.Methods {
+			name := method.Name.Lexeme
+			if other, exist := provider[name]; exist && !stmt.DefinesMethod(name) && other != trait.Name.Lexeme {
+				le.ReportResolveError(variable.Name, "Method '"+name+"' is provided by both trait '"+other+"' and '"+trait.Name.Lexeme+"', class '"+stmt.Name.Lexeme+"' must override it.")
+			}
+			provider[name] = trait.Name.Lexeme
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 var (
 	currentClass    ClassType    = None
 	currentCallable CallableType = None
+	inGenerator     bool         // 当前的函数中是否有yield
 )
 
 // Parser -> Resolver -> Interpreter
@@ -17,10 +18,13 @@ var (
 type Resolver struct {
 	interpreter *interpreter.Interpreter
 	scopes      *Stack
+	// traits 记录每一层作用域中声明的trait，用于检查混入时的方法冲突。
+	// traits[0]是全局作用域，traits[n]对应scopes中的第n-1个Scope
+	traits []map[string]*parser2.TraitDeclStmt
 }
 
 func NewResolver(interpreter *interpreter.Interpreter) *Resolver {
-	return &Resolver{interpreter: interpreter, scopes: NewStack()}
+	return &Resolver{interpreter: interpreter, scopes: NewStack(), traits: []map[string]*parser2.TraitDeclStmt{{}}}
 }
 
 func (r *Resolver) ResolveStmt(statements ...parser2.Stmt) {
@@ -44,7 +48,9 @@ func (r *Resolver) resolveLocal(expr parser2.Expr, token *token.Token) {
 }
 
 func (r *Resolver) resolveFunction(stmt *parser2.FuncDeclStmt, ct CallableType) {
-	currentCallable = ct
+	// 函数可以嵌套定义（比如在方法中定义匿名函数），resolve完毕后要恢复外层函数的类型
+	enclosingCallable, enclosingGenerator := currentCallable, inGenerator
+	currentCallable, inGenerator = ct, stmt.Generator
 	r.beginScope()
 	for _, param := range stmt.Params {
 		r.declare(param)
@@ -52,4 +58,5 @@ func (r *Resolver) resolveFunction(stmt *parser2.FuncDeclStmt, ct CallableType)
 	}
 	r.ResolveStmt(stmt.Body.Stmts...)
 	r.endScope()
+	currentCallable, inGenerator = enclosingCallable, enclosingGenerator
 }
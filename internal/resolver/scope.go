@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"GLox/internal/parser"
 	"GLox/internal/scanner/token"
 )
 
@@ -8,13 +9,17 @@ type Scope map[string]bool
 
 func (r *Resolver) beginScope() {
 	r.scopes.Push(make(Scope))
+	r.traits = append(r.traits, make(map[string]*parser.TraitDeclStmt))
 }
 
 func (r *Resolver) endScope() {
 	r.scopes.Pop()
+	r.traits = r.traits[:len(r.traits)-1]
 }
 
 func (r *Resolver) declare(token *token.Token) {
+	// 同名的声明遮蔽了之前的trait，VisitTraitDeclStmt在declare之后才记录trait
+	delete(r.traits[len(r.traits)-1], token.Lexeme)
 	if r.scopes.isEmpty() {
 		return
 	}
@@ -29,3 +34,23 @@ func (r *Resolver) define(token *token.Token) {
 
 	r.scopes.Peek().(Scope)[token.Lexeme] = true
 }
+
+func (r *Resolver) declareTrait(stmt *parser.TraitDeclStmt) {
+	r.traits[len(r.traits)-1][stmt.Name.Lexeme] = stmt
+}
+
+// lookupTrait 和resolveLocal一样从内向外查找name，找到的声明不是trait（比如同名的参数）时返回nil
+func (r *Resolver) lookupTrait(name string) *parser.TraitDeclStmt {
+	for i := len(r.traits) - 1; i >= 0; i-- {
+		if trait, ok := r.traits[i][name]; ok {
+			return trait
+		}
+		if i > 0 {
+			if _, ok := r.scopes.Get(i - 1).(Scope)[name]; ok {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
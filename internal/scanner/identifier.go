@@ -2,6 +2,7 @@ package scanner
 
 import (
 	"GLox/internal/scanner/token"
+	"sort"
 )
 
 var keywords map[string]token.TokenType
@@ -24,6 +25,19 @@ func init() {
 	keywords["true"] = token.TRUE
 	keywords["var"] = token.VAR
 	keywords["while"] = token.WHILE
+	keywords["trait"] = token.TRAIT
+	keywords["yield"] = token.YIELD
+}
+
+// Keywords 返回所有的关键字，按字母顺序排列
+func Keywords() []string {
+	var words []string
+	for word := range keywords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	return words
 }
 
 func (s *Scanner) addIdentifier() {
@@ -1,22 +1,24 @@
 package scanner
 
 import (
-	"GLox/internal/loxerror"
+	"GLox/internal/numeric"
 	"GLox/internal/scanner/token"
+	"math/big"
 	"strconv"
+	"strings"
 )
 
 // addStrLiteral 获取source中的字符串字面量
 func (s *Scanner) addStrLiteral() {
 	for s.peek() != '"' && !s.isAtEnd() {
-		if s.peek() == '\n' {
-			s.line++
-		}
 		s.advance()
+		if s.previous() == '\n' {
+			s.newLine()
+		}
 	}
 
 	if s.isAtEnd() {
-		loxerror.ReportLexError(s.line, "", "Unterminated string.")
+		s.error("Unterminated string.")
 		return
 	}
 	// 注意这里要consume掉最后一个 " 号
@@ -25,19 +27,114 @@ func (s *Scanner) addStrLiteral() {
 	s.addToken(token.STRING, s.source[s.start+1:s.current-1])
 }
 
+// addNumberLiteral 扫描数字字面量：十进制的数字可以有小数部分和指数（如 1.5e-3），0x和0b开头的是十六进制和二进制整数，
+// 数字之间可以用 '_' 分隔（如 1_000_000）。字面量的值的类型由number mode决定，带 'd' 后缀的（如 1.10d）总是Decimal，
+// 见numeric包
 func (s *Scanner) addNumberLiteral() {
-	for s.isDigit(s.peek()) {
-		s.advance()
+	if s.previous() == '0' && strings.ContainsRune("xXbB", rune(s.peek())) {
+		s.addIntegerLiteral()
+		return
 	}
+
+	s.digits(isDecimal)
 	// 小数点不能出现在数字字面量的最后一位
 	if s.peek() == '.' && s.isDigit(s.peekNext()) {
 		// consume掉 '.'
 		s.advance()
-		for s.isDigit(s.peek()) {
-			s.advance()
+		s.digits(isDecimal)
+	}
+	// e后面有数字才是指数，符号是可选的
+	if s.peek() == 'e' || s.peek() == 'E' {
+		exponent := s.current + 1
+		if exponent < len(s.source) && (s.source[exponent] == '+' || s.source[exponent] == '-') {
+			exponent++
+		}
+		if exponent < len(s.source) && s.isDigit(s.source[exponent]) {
+			s.current = exponent
+			s.digits(isDecimal)
 		}
 	}
 
-	literal, _ := strconv.ParseFloat(s.source[s.start:s.current], 64)
+	text := s.source[s.start:s.current]
+	if (s.peek() == 'd' || s.peek() == 'D') && !s.isAlphaDigit(s.peekNext()) {
+		s.advance()
+	}
+
+	literal, ok := s.decimalValue(strings.ReplaceAll(text, "_", ""), s.previous() == 'd' || s.previous() == 'D')
+	if !ok || !separated(text, isDecimal) {
+		s.error("Invalid number literal " + s.source[s.start:s.current] + ".")
+		return
+	}
 	s.addToken(token.NUMBER, literal)
 }
+
+// decimalValue 返回十进制字面量的值，suffix表示字面量带有 'd' 后缀
+func (s *Scanner) decimalValue(text string, suffix bool) (interface{}, bool) {
+	switch {
+	case suffix, s.numbers == numeric.Exact && strings.ContainsAny(text, ".eE"):
+		return numeric.ParseDecimal(text)
+	case s.numbers == numeric.Exact:
+		return new(big.Int).SetString(text, 10)
+	}
+
+	value, err := strconv.ParseFloat(text, 64)
+	return value, err == nil
+}
+
+// addIntegerLiteral 扫描0x或者0b开头的整数，current指向 'x' 或者 'b'
+func (s *Scanner) addIntegerLiteral() {
+	base, isDigit := 16, isHex
+	if prefix := s.advance(); prefix == 'b' || prefix == 'B' {
+		base, isDigit = 2, isBinary
+	}
+	s.digits(isDigit)
+
+	text := s.source[s.start:s.current]
+	digits := text[2:]
+	value, ok := new(big.Int).SetString(strings.ReplaceAll(digits, "_", ""), base)
+	if !ok || !separated(digits, isDigit) {
+		s.error("Invalid number literal " + text + ".")
+		return
+	}
+
+	if s.numbers == numeric.Exact {
+		s.addToken(token.NUMBER, value)
+		return
+	}
+	// float模式下超过 2^53 的整数不能精确表示
+	if value.Cmp(big.NewInt(numeric.MaxExactInteger)) > 0 {
+		s.error("Number literal " + text + " is too large for a float, use exact numbers.")
+		return
+	}
+	s.addToken(token.NUMBER, float64(value.Uint64()))
+}
+
+// digits consume连续的数字和 '_'
+func (s *Scanner) digits(isDigit func(c byte) bool) {
+	for isDigit(s.peek()) || s.peek() == '_' {
+		s.advance()
+	}
+}
+
+// separated 判断text中的 '_' 是否都在两个数字之间
+func separated(text string, isDigit func(c byte) bool) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] == '_' && (i == 0 || i == len(text)-1 || !isDigit(text[i-1]) || !isDigit(text[i+1])) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isDecimal(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHex(c byte) bool {
+	return isDecimal(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isBinary(c byte) bool {
+	return c == '0' || c == '1'
+}
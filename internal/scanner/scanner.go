@@ -2,31 +2,89 @@ package scanner
 
 import (
 	"GLox/internal/loxerror"
+	"GLox/internal/numeric"
 	"GLox/internal/scanner/token"
 	"GLox/utils"
+	"math/big"
+	"strings"
 )
 
+// Pragma 是源码中的一个 "//glox:numbers=" 注释，Offset是注释开始的位置，Mode是它设置的数字模式
+type Pragma struct {
+	Offset int
+	Mode   numeric.Mode
+}
+
 type Scanner struct {
 	source  string
+	end     int          // 扫描到end为止，见NewRangeScanner
+	numbers numeric.Mode // 数字字面量的类型，见SetNumberMode
+	pragmas []Pragma
+	// collect 为true时词法错误保存在errors中，而不是通过loxerror.ReportLexError报告，见CollectErrors
+	collect bool
+	errors  loxerror.ParseErrors
 	tokens  []*token.Token
 	start   int // start指向被扫描词素的第一个字符
 	current int // current指向当前处理的字符
 	line    int // line指向当前行数
+
+	lineStart int // 当前行第一个字符的位置
+	startLine int // 被扫描词素的第一个字符所在的行，多行的字符串结束时line已经改变
+	column    int // 被扫描词素的第一个字符所在的列，从1开始
 }
 
 func NewScanner(source string) *Scanner {
-	return &Scanner{source: source, line: 1}
+	return &Scanner{source: source, end: len(source), line: 1}
+}
+
+// NewRangeScanner 从start开始扫描，到end为止，得到的token的行号、列号和Offset仍然是相对于整个source的，
+// line是start所在的行。用于在编辑之后重新扫描源码的一部分。跨过end的token或者注释会被完整地扫描，
+// 这时EOF的Offset大于end
+func NewRangeScanner(source string, start, end, line int) *Scanner {
+	return &Scanner{
+		source:    source,
+		end:       end,
+		start:     start,
+		current:   start,
+		line:      line,
+		lineStart: strings.LastIndexByte(source[:start], '\n') + 1,
+	}
+}
+
+// SetNumberMode 设置没有后缀的数字字面量的类型，源码中的 "//glox:numbers=exact" 会覆盖这里的设置
+func (s *Scanner) SetNumberMode(mode numeric.Mode) {
+	s.numbers = mode
+}
+
+// CollectErrors 让ScanTokens把词法错误保存下来（通过Errors获取），而不是输出到log、设置全局的loxerror.HadError。
+// 同时扫描多个文件的工具（比如编辑器）需要这样做
+func (s *Scanner) CollectErrors() {
+	s.collect = true
+}
+
+// Errors 返回CollectErrors之后扫描遇到的词法错误，错误的位置是出错的词素
+func (s *Scanner) Errors() loxerror.ParseErrors {
+	return s.errors
+}
+
+// Pragmas 返回ScanTokens遇到的所有合法的pragma，按出现的顺序排列
+func (s *Scanner) Pragmas() []Pragma {
+	return s.pragmas
 }
 
 func (s *Scanner) ScanTokens() []*token.Token {
-	for !s.isAtEnd() {
+	for s.current < s.end {
 		// 下一轮扫描的开始位置就是上一轮扫描的结束位置
 		s.start = s.current
+		s.startLine, s.column = s.line, s.start-s.lineStart+1
 		s.scanToken()
 	}
 
 	// After scanning source, add EOF to tokens
-	s.tokens = append(s.tokens, token.NewToken(token.EOF, "", nil, s.line))
+	eof := token.NewToken(token.EOF, "", nil, s.line)
+	eof.Column = s.current - s.lineStart + 1
+	eof.Offset = s.current
+	s.tokens = append(s.tokens, eof)
 	return s.tokens
 }
 
@@ -40,28 +98,71 @@ func (s *Scanner) scanToken() {
 		s.addToken(token.LEFT_BRACE, nil)
 	case '}':
 		s.addToken(token.RIGHT_BRACE, nil)
+	case '[':
+		s.addToken(token.LEFT_BRACKET, nil)
+	case ']':
+		s.addToken(token.RIGHT_BRACKET, nil)
 	case ',':
 		s.addToken(token.COMMA, nil)
 	case '.':
 		s.addToken(token.DOT, nil)
 	case '-':
-		s.addToken(token.MINUS, nil)
+		if s.matchNext('-') {
+			s.addToken(token.MINUS_MINUS, s.one())
+		} else {
+			s.addToken(utils.Ternary(s.matchNext('='), token.MINUS_EQUAL, token.MINUS), nil)
+		}
 	case '+':
-		s.addToken(token.PLUS, nil)
+		if s.matchNext('+') {
+			s.addToken(token.PLUS_PLUS, s.one())
+		} else {
+			s.addToken(utils.Ternary(s.matchNext('='), token.PLUS_EQUAL, token.PLUS), nil)
+		}
 	case ';':
 		s.addToken(token.SEMICOLON, nil)
 	case '*':
-		s.addToken(token.STAR, nil)
+		s.addToken(utils.Ternary(s.matchNext('='), token.STAR_EQUAL, token.STAR), nil)
+	case ':':
+		s.addToken(token.COLON, nil)
+	case '?':
+		if s.matchNext('?') {
+			s.addToken(token.QUESTION_QUESTION, nil)
+		} else {
+			s.addToken(utils.Ternary(s.matchNext('.'), token.QUESTION_DOT, token.QUESTION), nil)
+		}
 	// Look ahead 一个字符
 	case '!':
 		s.addToken(utils.Ternary(s.matchNext('='), token.BANG_EQUAL, token.BANG), nil)
 		//s.addToken(TokenType((utils.Ternary(s.matchNext('='), BANG_EQUAL, BANG)).(int)), nil)
 	case '=':
-		s.addToken(utils.Ternary(s.matchNext('='), token.EQUAL_EQUAL, token.EQUAL), nil)
+		if s.matchNext('>') {
+			s.addToken(token.ARROW, nil)
+		} else {
+			s.addToken(utils.Ternary(s.matchNext('='), token.EQUAL_EQUAL, token.EQUAL), nil)
+		}
 	case '<':
-		s.addToken(utils.Ternary(s.matchNext('='), token.LESS_EQUAL, token.LESS), nil)
+		if s.matchNext('<') {
+			s.addToken(token.LESS_LESS, nil)
+		} else {
+			s.addToken(utils.Ternary(s.matchNext('='), token.LESS_EQUAL, token.LESS), nil)
+		}
 	case '>':
-		s.addToken(utils.Ternary(s.matchNext('='), token.GREATER_EQUAL, token.GREATER), nil)
+		if s.matchNext('>') {
+			s.addToken(token.GREATER_GREATER, nil)
+		} else {
+			s.addToken(utils.Ternary(s.matchNext('='), token.GREATER_EQUAL, token.GREATER), nil)
+		}
+	// 位运算
+	case '%':
+		s.addToken(token.PERCENT, nil)
+	case '&':
+		s.addToken(token.AMPERSAND, nil)
+	case '|':
+		s.addToken(token.PIPE, nil)
+	case '^':
+		s.addToken(token.CARET, nil)
+	case '~':
+		s.addToken(token.TILDE, nil)
 	// '/' 需要特殊处理，因为注释也是以 '/' 开头
 	case '/':
 		if s.matchNext('/') {
@@ -69,13 +170,14 @@ func (s *Scanner) scanToken() {
 			for s.peek() != '\n' {
 				s.advance()
 			}
+			s.pragma(s.source[s.start:s.current])
 		} else {
-			s.addToken(token.SLASH, nil)
+			s.addToken(utils.Ternary(s.matchNext('='), token.SLASH_EQUAL, token.SLASH), nil)
 		}
 	case ' ', '\r', '\t':
 		break
 	case '\n':
-		s.line++
+		s.newLine()
 	// 字符串以 '"' 开头
 	case '"':
 		s.addStrLiteral()
@@ -87,13 +189,58 @@ func (s *Scanner) scanToken() {
 			// 假设匹配到的全是identifier，之后再和keyword区分（最长匹配原则）
 			s.addIdentifier()
 		} else {
-			loxerror.ReportLexError(s.line, "", "Unexpected character "+string(c))
+			s.error("Unexpected character " + string(c))
 		}
 	}
 }
 
 func (s *Scanner) addToken(tokenType token.TokenType, literal interface{}) {
-	s.tokens = append(s.tokens, token.NewToken(tokenType, s.source[s.start:s.current], literal, s.line))
+	t := token.NewToken(tokenType, s.source[s.start:s.current], literal, s.startLine)
+	t.Column, t.Offset = s.column, s.start
+	s.tokens = append(s.tokens, t)
+}
+
+// error 报告当前词素中的词法错误
+func (s *Scanner) error(message string) {
+	if !s.collect {
+		loxerror.ReportLexError(s.line, "", message)
+		return
+	}
+
+	t := token.NewToken(token.ILLEGAL, s.source[s.start:s.current], nil, s.startLine)
+	t.Column, t.Offset = s.column, s.start
+	s.errors = append(s.errors, loxerror.NewParseError(t, message))
+}
+
+// one 是 "++" 和 "--" 的Literal，也就是自增的步长，和整数字面量的类型相同
+func (s *Scanner) one() interface{} {
+	if s.numbers == numeric.Exact {
+		return big.NewInt(1)
+	}
+
+	return float64(1)
+}
+
+// pragma 处理 "//glox:numbers=exact" 形式的注释，它决定之后的数字字面量的类型，一般写在文件的开头
+func (s *Scanner) pragma(comment string) {
+	name := strings.TrimPrefix(comment, "//glox:numbers=")
+	if name == comment {
+		return
+	}
+
+	mode, ok := numeric.ParseMode(strings.TrimSpace(name))
+	if !ok {
+		s.error("Unknown number mode '" + name + "'.")
+		return
+	}
+	s.numbers = mode
+	s.pragmas = append(s.pragmas, Pragma{Offset: s.start, Mode: mode})
+}
+
+// newLine 在consume掉 '\n' 之后调用
+func (s *Scanner) newLine() {
+	s.line++
+	s.lineStart = s.current
 }
 
 func (s *Scanner) isAtEnd() bool {
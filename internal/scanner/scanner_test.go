@@ -0,0 +1,161 @@
+package scanner
+
+import (
+	"GLox/internal/loxerror"
+	"GLox/internal/numeric"
+	"GLox/internal/scanner/token"
+	"fmt"
+	"testing"
+)
+
+func TestScanTokens_Positions(t *testing.T) {
+	source := "var s = \"a\nb\";\n  print s;"
+	expected := []struct {
+		tokenType token.TokenType
+		line      int
+		column    int
+	}{
+		{token.VAR, 1, 1},
+		{token.IDENTIFIER, 1, 5},
+		{token.EQUAL, 1, 7},
+		{token.STRING, 1, 9},
+		{token.SEMICOLON, 2, 3},
+		{token.PRINT, 3, 3},
+		{token.IDENTIFIER, 3, 9},
+		{token.SEMICOLON, 3, 10},
+		{token.EOF, 3, 11},
+	}
+
+	tokens := NewScanner(source).ScanTokens()
+	if len(tokens) != len(expected) {
+		t.Fatalf("expected %d tokens, but got %d: %v", len(expected), len(tokens), tokens)
+	}
+	for i, e := range expected {
+		tok := tokens[i]
+		if tok.Type != e.tokenType || tok.Line != e.line || tok.Column != e.column {
+			t.Errorf("token %d: expected %v at %d:%d, but got %v at %d:%d", i, e.tokenType, e.line, e.column, tok.Type, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestRangeScanner(t *testing.T) {
+	source := "var a;\n  print a; // x\nprint b;"
+	start := len("var a;\n")
+
+	// 只扫描第二行，位置仍然相对于整个source
+	tokens := NewRangeScanner(source, start, start+len("  print a;"), 2).ScanTokens()
+	if len(tokens) != 4 {
+		t.Fatalf("expected 4 tokens, but got %d: %v", len(tokens), tokens)
+	}
+	if print := tokens[0]; print.Line != 2 || print.Column != 3 || print.Offset != start+2 {
+		t.Errorf("expected print at 2:3 offset %d, but got %d:%d offset %d", start+2, print.Line, print.Column, print.Offset)
+	}
+	if eof := tokens[3]; eof.Offset != start+len("  print a;") {
+		t.Errorf("expected EOF at the end of range, but got offset %d", eof.Offset)
+	}
+
+	// 注释跨过了end，会被完整地扫描
+	end := len(source) - len("x\nprint b;")
+	if eof := NewRangeScanner(source, start, end, 2).ScanTokens()[3]; eof.Offset <= end {
+		t.Errorf("expected EOF after %d, but got offset %d", end, eof.Offset)
+	}
+}
+
+func TestTokenType_String(t *testing.T) {
+	if s := token.LEFT_PAREN.String(); s != "LEFT_PAREN" {
+		t.Errorf("expected LEFT_PAREN, but got %s", s)
+	}
+	if s := token.EOF.String(); s != "EOF" {
+		t.Errorf("expected EOF, but got %s", s)
+	}
+	if s := token.NewToken(token.IDENTIFIER, "foo", "foo", 1).String(); s != "IDENTIFIER foo foo" {
+		t.Errorf("expected the type name in the token string, but got %q", s)
+	}
+}
+
+func TestScanNumberLiterals(t *testing.T) {
+	tests := []struct {
+		source string
+		value  float64
+	}{
+		{"42", 42},
+		{"3.25", 3.25},
+		{"1_000_000", 1000000},
+		{"1e9", 1e9},
+		{"2.5E-3", 2.5e-3},
+		{"1e+2", 100},
+		{"0xff", 255},
+		{"0XdEaD_bEeF", 0xdeadbeef},
+		{"0b1010_0101", 0xa5},
+	}
+
+	for _, test := range tests {
+		tokens := NewScanner(test.source).ScanTokens()
+		if len(tokens) != 2 || tokens[0].Type != token.NUMBER || tokens[0].Literal != test.value || tokens[0].Lexeme != test.source {
+			t.Errorf("%s: expected NUMBER %v, but got %v", test.source, test.value, tokens)
+		}
+	}
+
+	// 'e' 后面没有数字的时候不属于数字字面量
+	if tokens := NewScanner("1e").ScanTokens(); len(tokens) != 3 || tokens[1].Type != token.IDENTIFIER {
+		t.Errorf("expected NUMBER IDENTIFIER, but got %v", tokens)
+	}
+}
+
+func TestScanInvalidNumberLiterals(t *testing.T) {
+	defer func(hadError bool) { loxerror.HadError = hadError }(loxerror.HadError)
+
+	for _, source := range []string{"1__0", "1_", "0x", "0x_1", "0b2", "0x1_0000_0000_0000_0000",
+		"0xFFFFFFFFFFFFFFFF", "0x7FFFFFFFFFFFFFFF", "0x20000000000001"} {
+		loxerror.HadError = false
+		NewScanner(source).ScanTokens()
+		if !loxerror.HadError {
+			t.Errorf("%s: expected a lex error", source)
+		}
+	}
+}
+
+func TestScanExactNumberLiterals(t *testing.T) {
+	tests := []struct {
+		source string
+		mode   numeric.Mode
+		want   string
+		kind   string
+	}{
+		{"1.10d", numeric.Float, "1.10", "numeric.Decimal"},
+		{"1.10", numeric.Float, "1.1", "float64"},
+		{"1.10", numeric.Exact, "1.10", "numeric.Decimal"},
+		{"0x1_0000_0000_0000_0000", numeric.Exact, "18446744073709551616", "*big.Int"},
+		{"0x20000000000000", numeric.Float, "9.007199254740992e+15", "float64"},
+		{"//glox:numbers=exact\n12", numeric.Float, "12", "*big.Int"},
+	}
+
+	for _, test := range tests {
+		s := NewScanner(test.source)
+		s.SetNumberMode(test.mode)
+		tokens := s.ScanTokens()
+		literal := tokens[len(tokens)-2].Literal
+		if got, kind := fmt.Sprint(literal), fmt.Sprintf("%T", literal); got != test.want || kind != test.kind {
+			t.Errorf("%q: got %s %s, want %s %s", test.source, kind, got, test.kind, test.want)
+		}
+	}
+}
+
+func TestScanCollectErrors(t *testing.T) {
+	defer func(hadError bool) { loxerror.HadError = hadError }(loxerror.HadError)
+	loxerror.HadError = false
+
+	s := NewScanner("var a = 0x;\nprint @;\n\"abc")
+	s.CollectErrors()
+	s.ScanTokens()
+
+	want := "[parse error] line 1:9: Invalid number literal 0x.\n" +
+		"[parse error] line 2:7: Unexpected character @\n" +
+		"[parse error] line 3:1: Unterminated string."
+	if got := s.Errors().Error(); got != want {
+		t.Errorf("got errors:\n%s\nwant:\n%s", got, want)
+	}
+	if loxerror.HadError {
+		t.Errorf("CollectErrors should not set loxerror.HadError")
+	}
+}
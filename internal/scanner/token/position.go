@@ -0,0 +1,31 @@
+package token
+
+import "fmt"
+
+// Position 是源码中的位置，Line和Column都从1开始（Column按字节计算），Line为0表示位置未知，
+// 比如语法脱糖生成的token
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// Before 判断p是否在q之前
+func (p Position) Before(q Position) bool {
+	if p.Line != q.Line {
+		return p.Line < q.Line
+	}
+
+	return p.Column < q.Column
+}
+
+func (p Position) String() string {
+	if !p.IsValid() {
+		return "-"
+	}
+
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
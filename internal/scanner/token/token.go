@@ -1,19 +1,36 @@
 package token
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
+
+//go:generate stringer -type=TokenType
+
+// TokenType 的String方法由stringer生成，见tokentype_string.go
+type TokenType int
 
 const (
-	LEFT_PAREN  = iota // '('
-	RIGHT_PAREN        // ')'
-	LEFT_BRACE         // '{'
-	RIGHT_BRACE        // '}'
-	COMMA              // ','
-	DOT                // '.'
-	MINUS              // '-'
-	PLUS               // '+'
-	SEMICOLON          // ';'
-	SLASH              // '/'
-	STAR               // '*'
+	LEFT_PAREN    TokenType = iota // '('
+	RIGHT_PAREN                    // ')'
+	LEFT_BRACE                     // '{'
+	RIGHT_BRACE                    // '}'
+	LEFT_BRACKET                   // '['
+	RIGHT_BRACKET                  // ']'
+	COMMA                          // ','
+	DOT                            // '.'
+	MINUS                          // '-'
+	PLUS                           // '+'
+	SEMICOLON                      // ';'
+	SLASH                          // '/'
+	STAR                           // '*'
+	COLON                          // ':'
+	QUESTION                       // '?'
+	PERCENT                        // '%'
+	AMPERSAND                      // '&'
+	PIPE                           // '|'
+	CARET                          // '^'
+	TILDE                          // '~'
 
 	BANG
 	BANG_EQUAL
@@ -23,6 +40,17 @@ const (
 	GREATER_EQUAL
 	LESS
 	LESS_EQUAL
+	LESS_LESS
+	GREATER_GREATER
+	PLUS_EQUAL
+	MINUS_EQUAL
+	STAR_EQUAL
+	SLASH_EQUAL
+	PLUS_PLUS
+	MINUS_MINUS
+	ARROW
+	QUESTION_QUESTION
+	QUESTION_DOT
 
 	IDENTIFIER
 	STRING
@@ -44,17 +72,21 @@ const (
 	TRUE
 	VAR
 	WHILE
+	TRAIT
+	YIELD
 
 	EOF
+	// ILLEGAL 是无法识别的词素，只用来标记词法错误的位置，不会出现在ScanTokens的结果中
+	ILLEGAL
 )
 
-type TokenType = int
-
 type Token struct {
 	Type    TokenType
 	Lexeme  string
 	Literal interface{}
 	Line    int
+	Column  int // 从1开始，按字节计算
+	Offset  int // 第一个字符在源码中的位置（字节），从0开始
 }
 
 func NewToken(tokenType TokenType, lexeme string, literal interface{}, line int) *Token {
@@ -66,6 +98,29 @@ func NewToken(tokenType TokenType, lexeme string, literal interface{}, line int)
 	}
 }
 
+// Pos 返回token第一个字符的位置
+func (t *Token) Pos() Position {
+	return Position{Line: t.Line, Column: t.Column}
+}
+
+// End 返回token最后一个字符之后的位置，多行字符串的结束位置在最后一行
+func (t *Token) End() Position {
+	end := t.Pos()
+	if i := strings.LastIndexByte(t.Lexeme, '\n'); i >= 0 {
+		end.Line += strings.Count(t.Lexeme, "\n")
+		end.Column = len(t.Lexeme) - i
+	} else {
+		end.Column += len(t.Lexeme)
+	}
+
+	return end
+}
+
+// EndOffset 返回token最后一个字符之后在源码中的位置（字节）
+func (t *Token) EndOffset() int {
+	return t.Offset + len(t.Lexeme)
+}
+
 func (t *Token) String() string {
-	return fmt.Sprintf("%d %s %v", t.Type, t.Lexeme, t.Literal)
+	return fmt.Sprintf("%v %s %v", t.Type, t.Lexeme, t.Literal)
 }
@@ -3,48 +3,223 @@ package main
 import (
 	"bytes"
 	"fmt"
-	"io/ioutil"
+	"go/format"
+	"go/token"
+	"path"
 	"strings"
 )
 
-func defineAst(outputDir string, baseName string, types []string) error {
-	f := outputDir + "/" + strings.ToLower(baseName) + ".go"
-	var buffer bytes.Buffer
-	buffer.WriteString("package parser\n\n")
-	buffer.WriteString("import \"GLox/scanner\"\n\n")
-	buffer.WriteString("type " + baseName + " interface" + " {\n\tAccept(visitor Visitor) interface{}\n}\n\n")
+const header = "// Code generated by tools/ast from %s; DO NOT EDIT.\n\npackage %s\n\n"
 
-	for _, t := range types {
-		ts := strings.Split(t, ":")
-		name := strings.TrimSpace(ts[0])
-		fieldList := strings.TrimSpace(ts[1])
-		defineType(&buffer, name, fieldList)
-		defineVisitor(&buffer, name)
-		buffer.WriteString("\n")
+// generator 根据schema生成语法树相关的代码，每个方法对应一个输出文件
+type generator struct {
+	schema *schema
+	source string // schema文件名，写在生成代码的头部
+	pkg    string
+	buffer bytes.Buffer
+}
+
+// files 返回 文件名 -> 格式化之后的代码
+func (g *generator) files() (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	for name, gen := range map[string]func(){
+		"expr.go":         g.exprs,
+		"statement.go":    g.stmts,
+		"visitor.go":      g.visitors,
+		"base_visitor.go": g.baseVisitor,
+		"walk.go":         g.walker,
+	} {
+		g.buffer.Reset()
+		gen()
+
+		src, err := g.format()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		files[name] = src
+	}
+
+	return files, nil
+}
+
+// format 加上文件头和用到的import，然后用gofmt格式化
+func (g *generator) format() ([]byte, error) {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, header, g.source, g.pkg)
+
+	body := g.buffer.String()
+	var imports []string
+	for _, imp := range g.schema.imports {
+		if strings.Contains(body, path.Base(imp)+".") {
+			imports = append(imports, imp)
+		}
+	}
+	if len(imports) > 0 {
+		out.WriteString("import (\n")
+		for _, imp := range imports {
+			fmt.Fprintf(&out, "\t%q\n", imp)
+		}
+		out.WriteString(")\n\n")
+	}
+	out.WriteString(body)
+
+	return format.Source(out.Bytes())
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buffer, format, args...)
+}
+
+func (g *generator) exprs() {
+	g.printf("type Expr interface {\n\tNode\n\tAccept(visitor ExprVisitor) (interface{}, error)\n}\n\n")
+	for _, n := range g.schema.exprs {
+		g.defineType(n)
+		g.definePosition(n)
+		g.printf("func (%s *%s) Accept(visitor ExprVisitor) (interface{}, error) {\n", n.receiver(), n.name)
+		g.printf("\treturn visitor.%s(%s)\n}\n\n", n.visitMethod(), n.receiver())
+	}
+}
+
+func (g *generator) stmts() {
+	g.printf("type Stmt interface {\n\tNode\n\tAccept(visitor StmtVisitor) error\n")
+	g.printf("\t// Line 返回statement开始的行号，无法确定的时候返回0，见position.go\n\tLine() int\n}\n\n")
+	for _, n := range g.schema.stmts {
+		g.defineType(n)
+		g.definePosition(n)
+		g.printf("func (%s *%s) Accept(visitor StmtVisitor) error {\n", n.receiver(), n.name)
+		g.printf("\treturn visitor.%s(%s)\n}\n\n", n.visitMethod(), n.receiver())
+	}
+}
+
+// defineType 生成节点的结构体和构造函数，构造函数的参数和字段一一对应
+func (g *generator) defineType(n *node) {
+	for _, doc := range n.doc {
+		g.printf("%s\n", doc)
+	}
+	g.printf("type %s struct {\n", n.name)
+	for _, f := range n.fields {
+		g.printf("\t%s %s\n", f.name, f.typ)
+	}
+	g.printf("}\n\n")
+
+	if n.custom {
+		return
 	}
 
-	return ioutil.WriteFile(f, buffer.Bytes(), 0644)
+	var params, values []string
+	for _, f := range n.fields {
+		params = append(params, param(f.name)+" "+f.typ)
+		values = append(values, f.name+": "+param(f.name))
+	}
+	g.printf("func New%s(%s) *%s {\n", n.name, strings.Join(params, ", "), n.name)
+	g.printf("\treturn &%s{%s}\n}\n\n", n.name, strings.Join(values, ", "))
 }
 
-func defineType(buffer *bytes.Buffer, typeName string, fieldList string) {
-	buffer.WriteString("type " + typeName + " struct" + " { \n")
+// definePosition 生成Node接口的Pos和End方法，范围由节点中的token和子节点计算，见position.go
+func (g *generator) definePosition(n *node) {
+	g.printf("func (%s *%s) Pos() token.Position {\n\tpos, _ := span(%s)\n\treturn pos\n}\n\n", n.receiver(), n.name, n.receiver())
+	g.printf("func (%s *%s) End() token.Position {\n\t_, end := span(%s)\n\treturn end\n}\n\n", n.receiver(), n.name, n.receiver())
+}
 
-	var filedName []string
-	fields := strings.Split(fieldList, ", ")
-	for _, filed := range fields {
-		filedName = append(filedName, strings.Split(filed, " ")[0])
-		buffer.WriteString("\t" + filed + "\n")
+func (g *generator) visitors() {
+	g.printf("type ExprVisitor interface {\n")
+	for _, n := range g.schema.exprs {
+		g.printf("\t%s(expr *%s) (interface{}, error)\n", n.visitMethod(), n.name)
+	}
+	g.printf("}\n\n")
+
+	g.printf("// StmtVisitor 中定义的方法相当于直接执行语句，所以不会有返回值\n")
+	g.printf("type StmtVisitor interface {\n")
+	for _, n := range g.schema.stmts {
+		g.printf("\t%s(stmt *%s) error\n", n.visitMethod(), n.name)
+	}
+	g.printf("}\n")
+}
+
+func (g *generator) baseVisitor() {
+	g.printf("// BaseVisitor 实现了ExprVisitor和StmtVisitor的所有方法，但是什么都不做。\n")
+	g.printf("// 嵌入到其他结构体中之后，只需要实现关心的那几个方法\n")
+	g.printf("type BaseVisitor struct{}\n\n")
+	for _, n := range g.schema.exprs {
+		g.printf("func (BaseVisitor) %s(expr *%s) (interface{}, error) { return nil, nil }\n\n", n.visitMethod(), n.name)
+	}
+	for _, n := range g.schema.stmts {
+		g.printf("func (BaseVisitor) %s(stmt *%s) error { return nil }\n\n", n.visitMethod(), n.name)
 	}
-	buffer.WriteString("}\n\n")
-	// 构造方法
-	buffer.WriteString("func New" + typeName + "(" + fieldList + ")" + " *" + typeName + " {\n")
-	buffer.WriteString(fmt.Sprintf("\treturn &%s{", typeName))
-	buffer.WriteString(strings.Join(filedName, ", "))
-	buffer.WriteString("}\n}\n\n")
 }
 
-func defineVisitor(buffer *bytes.Buffer, typeName string) {
-	receiver := string(typeName[0] + 32)
-	buffer.WriteString(fmt.Sprintf("func (%s *%s) accept(visitor Visitor) interface{} {\n", receiver, typeName))
-	buffer.WriteString(fmt.Sprintf("\treturn visitor.Visit%s(%s)\n}\n\n", typeName, receiver))
+// walker 生成 WalkChildren、RewriteChildren 和 walkTokens，它们按字段定义的顺序访问节点的直接子节点或者token
+func (g *generator) walker() {
+	g.printf("// WalkChildren 按字段定义的顺序对node的每个非nil子节点调用fn，不会递归\n")
+	g.printf("func WalkChildren(node Node, fn func(child Node)) {\n")
+	g.fieldSwitch(g.schema.isChild, func(f field) {
+		if strings.HasPrefix(f.typ, "[]") {
+			g.printf("\t\tfor _, child := range n.%s {\n\t\t\tif child != nil {\n\t\t\t\tfn(child)\n\t\t\t}\n\t\t}\n", f.name)
+		} else {
+			g.printf("\t\tif n.%s != nil {\n\t\t\tfn(n.%s)\n\t\t}\n", f.name, f.name)
+		}
+	})
+	g.printf("}\n\n")
+
+	g.printf("// RewriteChildren 用fn的返回值替换node的每个非nil子节点。fn返回nil（或者类型不符合字段的类型）时，\n")
+	g.printf("// 列表中的子节点会被删除，单个的子节点会被置为nil\n")
+	g.printf("func RewriteChildren(node Node, fn func(child Node) Node) {\n")
+	g.fieldSwitch(g.schema.isChild, func(f field) {
+		if typ := strings.TrimPrefix(f.typ, "[]"); typ != f.typ {
+			// 原地过滤列表，kept是保留下来的子节点
+			kept := param(f.name)
+			g.printf("\t\t%s := n.%s[:0]\n", kept, f.name)
+			g.printf("\t\tfor _, child := range n.%s {\n", f.name)
+			g.printf("\t\t\tif child == nil {\n\t\t\t\t%s = append(%s, child)\n", kept, kept)
+			g.printf("\t\t\t} else if child, ok := fn(child).(%s); ok && child != nil {\n", typ)
+			g.printf("\t\t\t\t%s = append(%s, child)\n\t\t\t}\n\t\t}\n", kept, kept)
+			g.printf("\t\tn.%s = %s\n", f.name, kept)
+		} else {
+			g.printf("\t\tif n.%s != nil {\n\t\t\tn.%s, _ = fn(n.%s).(%s)\n\t\t}\n", f.name, f.name, f.name, f.typ)
+		}
+	})
+	g.printf("}\n\n")
+
+	g.printf("// walkTokens 按字段定义的顺序对node中每个非nil的token调用fn，不包括子节点中的token\n")
+	g.printf("func walkTokens(node Node, fn func(t *token.Token)) {\n")
+	g.fieldSwitch(g.schema.isToken, func(f field) {
+		if strings.HasPrefix(f.typ, "[]") {
+			g.printf("\t\tfor _, t := range n.%s {\n\t\t\tfn(t)\n\t\t}\n", f.name)
+		} else {
+			g.printf("\t\tif n.%s != nil {\n\t\t\tfn(n.%s)\n\t\t}\n", f.name, f.name)
+		}
+	})
+	g.printf("}\n")
+}
+
+// fieldSwitch 生成一个对节点类型的type switch，每个分支中对满足filter的字段调用body，没有这种字段的节点不生成分支
+func (g *generator) fieldSwitch(filter func(typ string) bool, body func(f field)) {
+	g.printf("\tswitch n := node.(type) {\n")
+	for _, n := range append(append([]*node{}, g.schema.exprs...), g.schema.stmts...) {
+		var fields []field
+		for _, f := range n.fields {
+			if filter(f.typ) {
+				fields = append(fields, f)
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		g.printf("\tcase *%s:\n", n.name)
+		for _, f := range fields {
+			body(f)
+		}
+	}
+	g.printf("\t}\n")
+}
+
+// param 把字段名转换成参数或者局部变量的名字
+func param(name string) string {
+	name = strings.ToLower(name[:1]) + name[1:]
+	if token.IsKeyword(name) {
+		name += "_"
+	}
+
+	return name
 }
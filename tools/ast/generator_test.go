@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const parserDir = "../../internal/parser"
+
+// 修改了 ast.schema 或者生成器之后没有运行 go generate 时这个测试会失败
+func TestGeneratedFilesUpToDate(t *testing.T) {
+	files, err := generate(filepath.Join(parserDir, "ast.schema"), "parser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, src := range files {
+		current, err := os.ReadFile(filepath.Join(parserDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(current, src) {
+			t.Errorf("%s is out of date, run go generate ./internal/parser", name)
+		}
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	source := `# comment
+import "GLox/internal/scanner/token"
+
+// Pair doc
+expr Pair: Left Expr, Right Expr
+expr Name: Token *token.Token !
+stmt Loop: Body []Stmt
+`
+	s, err := parseSchema(strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.imports) != 1 || len(s.exprs) != 2 || len(s.stmts) != 1 {
+		t.Fatalf("imports=%v exprs=%d stmts=%d", s.imports, len(s.exprs), len(s.stmts))
+	}
+	if pair := s.exprs[0]; pair.visitMethod() != "VisitPairExpr" || len(pair.doc) != 1 || len(pair.fields) != 2 {
+		t.Errorf("unexpected node %+v", pair)
+	}
+	if !s.exprs[1].custom {
+		t.Errorf("Name should not have a generated constructor")
+	}
+	if loop := s.stmts[0]; loop.visitMethod() != "VisitLoopStmt" || !s.isChild(loop.fields[0].typ) {
+		t.Errorf("unexpected node %+v", loop)
+	}
+}
+
+func TestParseSchemaError(t *testing.T) {
+	for _, source := range []string{
+		"expr Pair Left Expr",
+		"node Pair: Left Expr",
+		"expr Pair: Left",
+		"expr Pair: Left Expr\nexpr Pair: Right Expr",
+	} {
+		if _, err := parseSchema(strings.NewReader(source)); err == nil {
+			t.Errorf("expect error for %q", source)
+		}
+	}
+}
@@ -3,34 +3,41 @@ package main
 import (
 	"flag"
 	"log"
+	"os"
+	"path/filepath"
 )
 
-var outputDir string
-
-func init() {
-	flag.StringVar(&outputDir, "o", "", "generate_ast <output directory>")
+// 根据schema生成语法树节点、visitor接口、BaseVisitor和WalkChildren，一般通过 go generate ./internal/parser 运行
+func main() {
+	schemaFile := flag.String("schema", "ast.schema", "schema file of the syntax tree")
+	outputDir := flag.String("o", ".", "output directory")
+	pkg := flag.String("package", "parser", "package name of the generated code")
 	flag.Parse()
+
+	files, err := generate(*schemaFile, *pkg)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(*outputDir, name), src, 0644); err != nil {
+			log.Fatalln(err)
+		}
+	}
 }
 
-func main() {
-	if len(outputDir) == 0 {
-		log.Fatalln("Usage: generate_ast <output directory>")
+func generate(schemaFile, pkg string) (map[string][]byte, error) {
+	f, err := os.Open(schemaFile)
+	if err != nil {
+		return nil, err
 	}
+	defer f.Close()
 
-	// 根据文法自动生成表达式的代码
-	//err := defineAst(outputDir, "Expr", []string{
-	//	"Binary   : left Expr, operator *scanner.Token, right Expr",
-	//	"Grouping : expression Expr",
-	//	"Literal  : value interface{}",
-	//	"Unary    : operator *scanner.Token, right Expr",
-	//})
-	//
-	//if err != nil {
-	//	log.Fatalln(err)
-	//}
+	s, err := parseSchema(f)
+	if err != nil {
+		return nil, err
+	}
 
-	_ = defineAst(outputDir, "Stmt", []string{
-		"ExprStmt : expr Expr",
-		"PrintStmt: expr Expr",
-	})
+	g := &generator{schema: s, source: filepath.Base(schemaFile), pkg: pkg}
+	return g.files()
 }
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// node 是schema中定义的一个语法树节点
+type node struct {
+	kind   string // "expr" 或者 "stmt"
+	name   string
+	doc    []string
+	fields []field
+	custom bool // 以 ! 结尾，构造函数是手写的
+}
+
+type field struct {
+	name string
+	typ  string
+}
+
+// schema 中每一行定义一个节点，格式为 <expr|stmt> Name: Field Type, Field Type, ...
+// 以 # 开头的行是schema自身的注释，以 // 开头的行是下一个节点的文档注释，import 行声明字段类型需要引入的包
+type schema struct {
+	imports []string
+	exprs   []*node
+	stmts   []*node
+	names   map[string]bool
+}
+
+func parseSchema(r io.Reader) (*schema, error) {
+	s := &schema{names: make(map[string]bool)}
+	var doc []string
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			doc = nil
+			continue
+		case strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "//"):
+			doc = append(doc, line)
+			continue
+		case strings.HasPrefix(line, "import "):
+			s.imports = append(s.imports, strings.Trim(strings.TrimSpace(line[len("import "):]), `"`))
+			continue
+		}
+
+		nd, err := parseNode(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		if s.names[nd.name] {
+			return nil, fmt.Errorf("line %d: duplicate node %s", n, nd.name)
+		}
+		nd.doc, doc = doc, nil
+
+		s.names[nd.name] = true
+		if nd.kind == "expr" {
+			s.exprs = append(s.exprs, nd)
+		} else {
+			s.stmts = append(s.stmts, nd)
+		}
+	}
+
+	return s, scanner.Err()
+}
+
+func parseNode(line string) (*node, error) {
+	nd := &node{}
+	if strings.HasSuffix(line, "!") {
+		nd.custom = true
+		line = strings.TrimSpace(strings.TrimSuffix(line, "!"))
+	}
+
+	parts := strings.SplitN(line, ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("expect ':' after node name")
+	}
+
+	header := strings.Fields(parts[0])
+	if len(header) != 2 || (header[0] != "expr" && header[0] != "stmt") {
+		return nil, fmt.Errorf("expect 'expr Name' or 'stmt Name' before ':'")
+	}
+	nd.kind, nd.name = header[0], header[1]
+
+	for _, f := range strings.Split(parts[1], ",") {
+		fs := strings.Fields(f)
+		if len(fs) != 2 {
+			return nil, fmt.Errorf("invalid field %q in %s", strings.TrimSpace(f), nd.name)
+		}
+		nd.fields = append(nd.fields, field{name: fs[0], typ: fs[1]})
+	}
+
+	return nd, nil
+}
+
+// visitMethod 返回节点在visitor中对应的方法名，如 VisitBinaryExpr、VisitPrintStmt，名字本身已经以Expr或者Stmt结尾的不再重复
+func (n *node) visitMethod() string {
+	suffix := "Expr"
+	if n.kind == "stmt" {
+		suffix = "Stmt"
+	}
+	if strings.HasSuffix(n.name, suffix) {
+		return "Visit" + n.name
+	}
+
+	return "Visit" + n.name + suffix
+}
+
+func (n *node) receiver() string {
+	return strings.ToLower(n.name[:1])
+}
+
+// isChild 判断字段的类型是否是语法树节点（或者节点的列表），walker只会访问这些字段
+func (s *schema) isChild(typ string) bool {
+	typ = strings.TrimPrefix(typ, "[]")
+	if typ == "Expr" || typ == "Stmt" {
+		return true
+	}
+
+	return strings.HasPrefix(typ, "*") && s.names[typ[1:]]
+}
+
+// isToken 判断字段的类型是否是token（或者token的列表）
+func (s *schema) isToken(typ string) bool {
+	return strings.TrimPrefix(typ, "[]") == "*token.Token"
+}